@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"SmashGlass/services"
+)
+
+// runCLI handles command line subcommands for theme and plugin authors.
+// It returns false when no subcommand was given and the overlay should
+// start as normal.
+func runCLI(args []string) (bool, int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	switch args[0] {
+	case "lint-theme":
+		attachConsole()
+		return true, lintThemeCommand(args[1:])
+	}
+
+	return false, 0
+}
+
+// lintThemeCommand checks a theme for positioning and sizing rules. The
+// argument is either an installed theme ID or a path to a CSS file.
+func lintThemeCommand(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: SmashGlass lint-theme <theme id | file.css>")
+		return 2
+	}
+
+	target := args[0]
+
+	var issues []services.ThemeLintIssue
+	var err error
+	if strings.HasSuffix(strings.ToLower(target), ".css") {
+		issues, err = services.LintThemeFile(target)
+	} else {
+		issues, err = services.NewStyleService().LintTheme(target)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("%s:%d: %s\n", target, issue.Line, issue.Message)
+	}
+
+	if len(issues) > 0 {
+		fmt.Printf("%d problem(s) found\n", len(issues))
+		return 1
+	}
+
+	fmt.Println("No problems found")
+	return 0
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package main
+
+// attachConsole has nothing to do outside Windows, where the output of the
+// release build already goes to the terminal it was run from
+func attachConsole() {}
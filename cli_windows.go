@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+const ATTACH_PARENT_PROCESS = ^uintptr(0) // (DWORD)-1
+
+var procAttachConsole = windows.NewLazySystemDLL("kernel32.dll").NewProc("AttachConsole")
+
+// attachConsole connects the output of a release build, which is linked as
+// a GUI application and starts without a console, to the console it was run
+// from. Output that has been redirected to a file or pipe is left alone.
+func attachConsole() {
+	if h, err := windows.GetStdHandle(windows.STD_OUTPUT_HANDLE); err == nil && h != 0 && h != windows.InvalidHandle {
+		return
+	}
+
+	if r1, _, _ := procAttachConsole.Call(ATTACH_PARENT_PROCESS); r1 == 0 {
+		return
+	}
+
+	out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	os.Stdout = out
+	os.Stderr = out
+}
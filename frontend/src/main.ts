@@ -3,12 +3,13 @@ import { createPinia } from 'pinia';
 import mitt from 'mitt';
 import '@/styles/css/legacy.css';
 
-import { MoveMainWindowToMonitor } from '@bindings/windowservice';
+import { MoveMainWindowToMonitor, MoveMainWindowToDisplay, SetOverlayDisplays } from '@bindings/windowservice';
 
 import App from './components/App.vue'
 import * as ConfirmDialog from 'vuejs-confirm-dialog';
 import form from './components/form';
 import * as dialog from './utils/dialog';
+import { isMainOverlay } from './utils/helpers';
 
 import { useConfigStore } from './stores/configStore';
 import { useOverlayStore } from './stores/overlayStore';
@@ -39,8 +40,16 @@ async function initApp() {
     // Dialog windows
     window.$dialog = dialog;
 
-    // Move to the default monitor
-    MoveMainWindowToMonitor(configStore.app.overlay.display);
+    // Move to the saved monitor and open the overlays on other monitors.
+    // Configs saved before monitor IDs only have an index.
+    if (isMainOverlay()) {
+        if (configStore.app.overlay.displayId) {
+            await MoveMainWindowToDisplay(configStore.app.overlay.displayId);
+        } else {
+            await MoveMainWindowToMonitor(configStore.app.overlay.display);
+        }
+        SetOverlayDisplays(configStore.app.overlay.extraDisplays);
+    }
 
     app.mount('#smashglass')
 }
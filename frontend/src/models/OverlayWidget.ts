@@ -30,6 +30,11 @@ export default class OverlayWidget {
      */
     name: string = 'widget';
 
+    /**
+     * Monitor ID of the overlay the widget is shown on, empty for the main one
+     */
+    display: string = '';
+
     /**
      * Position of the widget
      */
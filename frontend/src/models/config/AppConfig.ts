@@ -6,10 +6,20 @@ export default class AppConfig {
         theme: 'default',
         opacity: 0.9,
         zoom: .5,
-        display: 0,
+        display: 0, // Index of the monitor, only read when displayId is empty
+        displayId: '', // Monitor.ID from GetMonitors
+        extraDisplays: [] as string[], // Monitor IDs to open more overlays on
+        // Show, hide or dim the overlay depending on the focused window
+        focusRules: {
+            enabled: false,
+            default: 'hide',
+            dimOpacity: 0.3,
+            rules: [] as { match: string, pattern: string, action: string }[],
+        },
         widgets: {
             default: {
                 "chat": {
+                    display: '', // Monitor ID of the overlay to show on, empty for the main one
                     enabled: true,
                     position: { position: 'top-left', x: 0, y: 0, w: 400, h: 200, autoHeight: true, minHeight: 200 },
                     cfg: {
@@ -19,6 +29,7 @@ export default class AppConfig {
                     }
                 },
                 "guests": {
+                    display: '',
                     enabled: true,
                     position: { position: 'top-right', x: 0, y: 0, w: 250, h: 32, autoHeight: true, minHeight: 32 },
                     cfg: {
@@ -26,6 +37,7 @@ export default class AppConfig {
                     }
                 },
                 "gamepads": {
+                    display: '',
                     enabled: true,
                     position: { position: 'bottom-center', x: 0, y: 0, w: 100, h: 100, autoWidth: true, autoHeight: true },
                     cfg: {
@@ -33,6 +45,7 @@ export default class AppConfig {
                     }
                 },
                 "webcam": {
+                    display: '',
                     enabled: false,
                     position: { position: 'bottom-right', x: 0, y: 0, w: 280, h: 180 },
                     cfg: {
@@ -44,26 +57,6 @@ export default class AppConfig {
         },
     };
 
-    headless: {
-        name: string,
-        widgets: {}[],
-    }[] = [
-        {
-            name: 'Game',
-            widgets: []
-        },
-        {
-            name: 'Be Right Back',
-            widgets: []
-        },
-        {
-            name: 'Coming Soon',
-            widgets: []
-        }
-    ]
-
-    activeScene: number = 0;
-
     hotkeys = {
         'hotkey:chat': { modifiers: [17, 18], key: 67, event: 'hotkey:chat' }, // CTRL + ALT + C
         'hotkey:opacity:in': { modifiers: [17, 18], key: 38, event: 'hotkey:opacity:in' }, // CTRL + ALT + UP
@@ -72,12 +65,41 @@ export default class AppConfig {
         'hotkey:zoom:in': { modifiers: [17, 18], key: 39, event: 'hotkey:zoom:in' },
         'hotkey:menu': { modifiers: [17, 18], key: 112, event: 'hotkey:menu' },
         'hotkey:move': { modifiers: [17, 18], key: 77, event: 'hotkey:move' },
+        'hotkey:screenshot': { modifiers: [17, 18], key: 83, event: 'hotkey:screenshot' }, // CTRL + ALT + S
     };
 
+    // Screenshots taken with the screenshot hotkey
+    screenshots = {
+        folder: '', // Empty saves to Pictures/Smash Glass
+        includeOverlay: false,
+    };
+
+    // Hotkeys that send a message to Smash Soda, e.g. { event: 'chat:send', data: '"Be right back!"' }
+    commands: {
+        id: string,
+        label: string,
+        modifiers: number[],
+        key: number,
+        event: string,
+        data: string, // JSON
+    }[] = [];
+
     constructor(data?: Partial<AppConfig>) {
         if (data) {
             Object.assign(this, data);
 
+            // Fill in settings added since the config was saved
+            if (!this.overlay.focusRules) {
+                this.overlay.focusRules = new AppConfig().overlay.focusRules;
+            }
+            if (!this.overlay.extraDisplays) {
+                this.overlay.extraDisplays = [];
+            }
+            this.hotkeys = { ...new AppConfig().hotkeys, ...this.hotkeys };
+            if (!this.screenshots) {
+                this.screenshots = new AppConfig().screenshots;
+            }
+
             // Ensure display is a number
             if (typeof this.overlay.display === 'string') {
                 this.overlay.display = parseInt(this.overlay.display);
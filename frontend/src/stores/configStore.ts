@@ -2,12 +2,14 @@ import { defineStore } from 'pinia';
 import { ref } from 'vue';
 import { createConfirmDialog } from 'vuejs-confirm-dialog';
 import { Events } from '@wailsio/runtime';
-import { RegisterHotkey } from '../../bindings/SmashGlass/services/hotkeyservice';
+import { RegisterHotkey, UnregisterHotkey } from '../../bindings/SmashGlass/services/hotkeyservice';
 import { RegisterHotkeyArgs } from 'bindings/SmashGlass/services';
 import AppConfig from '@/models/config/AppConfig';
 import ConfigModalView from '@/components/overlay/config/ConfigModalView.vue';
 import { useOverlayStore } from './overlayStore';
-import { Focus, Blur } from '../../bindings/SmashGlass/services/windowservice';
+import { Focus, Blur, SetScreenshotFolder, CaptureScreenshot } from '../../bindings/SmashGlass/services/windowservice';
+import { SetFocusRules } from '../../bindings/SmashGlass/services/hookservice';
+import { isMainOverlay } from '@/utils/helpers';
 
 export const useConfigStore = defineStore('configStore', () => {
 
@@ -23,7 +25,22 @@ export const useConfigStore = defineStore('configStore', () => {
     async function init() {
         await resetConfig();
         await loadConfig();
+
+        // Overlays on other monitors share the config, so follow each
+        // other's changes
+        window.addEventListener('storage', (e: StorageEvent) => {
+            if (e.key !== 'config' || configModalEnabled.value) return;
+            loadConfig();
+            useOverlayStore().applyConfig();
+        });
+
+        // Hotkeys and the settings belong to the main overlay
+        if (!isMainOverlay()) return;
+
         await registerHotkeys();
+        await registerCommands();
+        await applyFocusRules();
+        await applyScreenshotFolder();
         await handleHotkeys();
 
         window.$eventBus.on('open:menu', (data: any) => {
@@ -165,6 +182,73 @@ export const useConfigStore = defineStore('configStore', () => {
             Key: app.value.hotkeys['hotkey:move'].key,
             Event: 'hotkey:move'
         });
+
+        await registerHotkey({
+            Name: 'hotkey:screenshot',
+            Modifiers: app.value.hotkeys['hotkey:screenshot'].modifiers,
+            Key: app.value.hotkeys['hotkey:screenshot'].key,
+            Event: 'hotkey:screenshot'
+        });
+    }
+
+    /**
+     * Sends the focus rules to the backend.
+     */
+    async function applyFocusRules() {
+        try {
+            await SetFocusRules(app.value.overlay.focusRules);
+        } catch (e) {
+            console.warn(e);
+        }
+    }
+
+    /**
+     * Tells the backend where to save screenshots.
+     */
+    async function applyScreenshotFolder() {
+        await SetScreenshotFolder(app.value.screenshots.folder);
+    }
+
+    /**
+     * Registers a hotkey that sends a command to Smash Soda.
+     */
+    async function registerCommand(command: AppConfig['commands'][number]) {
+        let data: any = command.data;
+        try {
+            data = JSON.parse(command.data);
+        } catch (e) {
+            // Plain text is sent as a string
+        }
+
+        await registerHotkey({
+            Name: 'command:' + command.id,
+            Modifiers: command.modifiers,
+            Key: command.key,
+            Event: '',
+            Command: { event: command.event, data: data }
+        } as RegisterHotkeyArgs);
+    }
+
+    /**
+     * Removes a command hotkey from the backend.
+     */
+    async function unregisterCommand(command: AppConfig['commands'][number]) {
+        try {
+            await UnregisterHotkey('command:' + command.id);
+        } catch (e) {
+            console.warn(e);
+        }
+    }
+
+    /**
+     * Registers the command hotkeys on the backend.
+     */
+    async function registerCommands() {
+        for (const command of app.value.commands) {
+            if (command.key > 0 && command.event) {
+                await registerCommand(command);
+            }
+        }
     }
 
     /**
@@ -195,6 +279,14 @@ export const useConfigStore = defineStore('configStore', () => {
 
         });
 
+        Events.On('hotkey:screenshot', async () => {
+            try {
+                await CaptureScreenshot('', app.value.screenshots.includeOverlay);
+            } catch (e) {
+                console.warn(e);
+            }
+        });
+
         Events.On('hotkey:menu', () => {
             openConfig();
 
@@ -208,6 +300,10 @@ export const useConfigStore = defineStore('configStore', () => {
         app,
         saveConfig,
         loadConfig,
-        resetConfig
+        resetConfig,
+        registerCommand,
+        unregisterCommand,
+        applyFocusRules,
+        applyScreenshotFolder
     }
 })
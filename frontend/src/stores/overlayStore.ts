@@ -3,7 +3,7 @@ import { ref } from 'vue';
 import { Events } from '@wailsio/runtime';
 import axios from 'axios';
 import { createConfirmDialog } from 'vuejs-confirm-dialog';
-import { Focus, Blur } from '../../bindings/SmashGlass/services/windowservice';
+import { FocusOverlay, Blur, GetOverlayDisplay, SetHitRegions } from '../../bindings/SmashGlass/services/windowservice';
 import { LoadPlugins, DeletePlugin } from '@bindings/pluginservice';
 import { GetOverlayStyles, GetOverlayThemeCSS } from '@bindings/styleservice';
 import { Plugin } from '@bindings/models';
@@ -19,6 +19,7 @@ import User from '@/models/User';
 import ChatMessage from '@/models/chat/ChatMessage';
 import ChatBubble from '@/models/chat/ChatBubble';
 import Pad from '@/models/Pad';
+import { overlayDisplay, windowMode } from '@/utils/helpers';
 
 import { useSocketStore } from './socketStore';
 import { useConfigStore } from './configStore';
@@ -67,6 +68,17 @@ export const useOverlayStore = defineStore('overlayStore', () => {
      */
     const interactable = ref(false);
 
+    /**
+     * The display the overlay is on. Widget positions are in logical pixels.
+     */
+    const display = ref({
+        overlay: overlayDisplay(),
+        id: '',
+        scale: 1,
+        physical: { width: 0, height: 0 },
+        logical: { width: 0, height: 0 },
+    });
+
     /**
      * The chat messages to be displayed on the overlay.
      */
@@ -111,6 +123,8 @@ export const useOverlayStore = defineStore('overlayStore', () => {
         await applyTheme(useConfigStore().app.overlay.theme);
         await loadPlugins();
         await handleHotkeys();
+        await handleDisplay();
+        handleHitRegions();
         initDefaultWidgets();
 
         window.$eventBus.on('chat:new', (data: any) => {
@@ -247,6 +261,7 @@ export const useOverlayStore = defineStore('overlayStore', () => {
             const widget = new OverlayWidget({
                 id: plugin.Meta.id,
                 name: plugin.Meta.name,
+                display: configStore.app.overlay.widgets.custom[plugin.Meta.name].display || '',
                 html: plugin.HTML,
                 css: plugin.CSS,
                 custom: true,
@@ -404,15 +419,18 @@ export const useOverlayStore = defineStore('overlayStore', () => {
      */
     async function handleHotkeys() {
 
+        // The studio and headless windows aren't interacted with
+        if (windowMode() !== 'overlay') return;
+
         Events.On('hotkey:chat', () => {
             if (locked.value || interactable.value) return;
             // Find chat widget
             const chatWidget = findWidgetByName('chat');
-            if (chatWidget) {
+            if (chatWidget && isShownHere(chatWidget)) {
                 chatWidget.active = !chatWidget.active;
 
                 if (chatWidget.active) {
-                    Focus();
+                    FocusOverlay(overlayDisplay());
                 } else {
                     Blur();
                 }
@@ -424,7 +442,7 @@ export const useOverlayStore = defineStore('overlayStore', () => {
             interactable.value = !interactable.value;
 
             if (interactable.value) {
-                Focus();
+                FocusOverlay(overlayDisplay());
             } else {
                 Blur();
             }
@@ -432,6 +450,101 @@ export const useOverlayStore = defineStore('overlayStore', () => {
 
     }
 
+    /**
+     * Tracks the display the overlay is on.
+     */
+    async function handleDisplay() {
+
+        // The studio and headless windows lay widgets out in the window
+        if (windowMode() !== 'overlay') return;
+
+        display.value = await GetOverlayDisplay(overlayDisplay());
+
+        Events.On('display:changed', (e: any) => {
+            // Every overlay gets the events for all of them
+            if ((e.data.overlay || '') !== overlayDisplay()) return;
+            display.value = e.data;
+        });
+
+    }
+
+    /**
+     * Lets elements marked with data-hit-region, such as a poll's buttons,
+     * take the mouse while the rest of the overlay lets it through. The
+     * regions are sent whenever they move.
+     */
+    function handleHitRegions() {
+
+        // Only the overlay lets the mouse through
+        if (windowMode() !== 'overlay') return;
+
+        let last = '[]';
+
+        setInterval(async () => {
+            const regions = Array.from(document.querySelectorAll('[data-hit-region]'))
+                .map(element => element.getBoundingClientRect())
+                .filter(rect => rect.width > 0 && rect.height > 0)
+                .map(rect => ({ x: rect.x, y: rect.y, width: rect.width, height: rect.height }));
+
+            const json = JSON.stringify(regions);
+            if (json === last) return;
+            last = json;
+
+            try {
+                await SetHitRegions(overlayDisplay(), regions);
+            } catch (e) {
+                console.warn(e);
+            }
+        }, 250);
+
+    }
+
+    /**
+     * Whether a widget is shown on this overlay. Widgets set to a display
+     * that no longer has an overlay are shown on the main one.
+     *
+     * @param widget The widget to check.
+     */
+    function isShownHere(widget: OverlayWidget) {
+        const extraDisplays = useConfigStore().app.overlay.extraDisplays;
+        const target = extraDisplays.includes(widget.display) ? widget.display : '';
+        return target === overlayDisplay();
+    }
+
+    /**
+     * Applies the saved config to the widgets and theme, after another
+     * overlay has changed it.
+     */
+    async function applyConfig() {
+        const configStore = useConfigStore();
+
+        widgets.value.forEach(widget => {
+            const config = configStore.app.overlay.widgets.default[widget.name];
+            if (!config) return;
+            widget.position = { ...widget.position, ...config.position };
+            widget.enabled = config.enabled;
+            widget.display = config.display || '';
+            widget.cfg = { ...widget.cfg, ...config.cfg };
+        });
+
+        customWidgets.value.forEach(widget => {
+            const config = configStore.app.overlay.widgets.custom[widget.name];
+            if (!config) return;
+            widget.position = config.position;
+            widget.display = config.display || '';
+            widget.cfg = config.cfg;
+            if (widget.enabled !== config.enabled) {
+                enableCustomWidget(widget.name, config.enabled);
+            }
+
+            if (widget.id) {
+                window.$eventBus.emit(`plugin:updated:${widget.id}`, widget.cfg);
+            }
+        });
+
+        await applyTheme(configStore.app.overlay.theme);
+    }
+
     /**
      * Find a widget by its name.
      * 
@@ -457,6 +570,7 @@ export const useOverlayStore = defineStore('overlayStore', () => {
             const chatWidget = new ChatWidget();
             Object.assign(chatWidget, {
                 enabled: configStore.app.overlay.widgets.default.chat.enabled,
+                display: configStore.app.overlay.widgets.default.chat.display || '',
                 position: {
                     ...chatWidget.position,
                     ...(configStore.app.overlay.widgets.default.chat.position as any)
@@ -470,6 +584,7 @@ export const useOverlayStore = defineStore('overlayStore', () => {
             const guestsWidget = new GuestsWidget();
             Object.assign(guestsWidget, {
                 enabled: configStore.app.overlay.widgets.default.guests.enabled,
+                display: configStore.app.overlay.widgets.default.guests.display || '',
                 position: {
                     ...guestsWidget.position,
                     ...(configStore.app.overlay.widgets.default.guests.position as any)
@@ -483,6 +598,7 @@ export const useOverlayStore = defineStore('overlayStore', () => {
             const gamepadsWidget = new GamepadsWidget();
             Object.assign(gamepadsWidget, {
                 enabled: configStore.app.overlay.widgets.default.gamepads.enabled,
+                display: configStore.app.overlay.widgets.default.gamepads.display || '',
                 position: {
                     ...gamepadsWidget.position,
                     ...(configStore.app.overlay.widgets.default.gamepads.position as any)
@@ -584,6 +700,9 @@ export const useOverlayStore = defineStore('overlayStore', () => {
         guests,
         pads,
         interactable,
+        display,
+        isShownHere,
+        applyConfig,
         init
     }
 
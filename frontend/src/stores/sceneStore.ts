@@ -0,0 +1,171 @@
+import { defineStore } from 'pinia';
+import { computed, ref } from 'vue';
+import { Events } from '@wailsio/runtime';
+import { GetScenes, SetActiveScene, CreateScene, UpdateScene, DeleteScene, SetSceneRules } from '@bindings/sceneservice';
+import Position from '@/interfaces/Position';
+
+/**
+ * A widget placed in a scene. Settings other than the position come from
+ * the overlay's widget.
+ */
+export interface SceneWidget {
+    name: string;
+    position: Position;
+}
+
+export interface Scene {
+    id: string;
+    name: string;
+    widgets: SceneWidget[];
+}
+
+/**
+ * Switches scene when a socket event or hotkey happens. Socket rules can
+ * match a field of the event's data, such as state = true.
+ */
+export interface SceneRule {
+    source: 'socket' | 'hotkey';
+    event: string;
+    field?: string;
+    value?: string;
+    scene: string;
+}
+
+export interface SceneRules {
+    enabled: boolean;
+    rules: SceneRule[];
+    debounceMs: number;
+}
+
+export const useSceneStore = defineStore('sceneStore', () => {
+
+    /**
+     * Every scene, in order.
+     */
+    const scenes = ref<Scene[]>([]);
+
+    /**
+     * The ID of the scene the headless window shows.
+     */
+    const active = ref('');
+
+    /**
+     * The scene the headless window shows.
+     */
+    const activeScene = computed(() => scenes.value.find(scene => scene.id === active.value));
+
+    /**
+     * The rules that switch scene.
+     */
+    const rules = ref<SceneRules>({ enabled: false, rules: [], debounceMs: 0 });
+
+    /**
+     * Initialize the store. Scenes live in the backend, which tells every
+     * window when they change.
+     */
+    async function init() {
+        apply(await GetScenes());
+
+        Events.On('scenes:changed', (e: any) => {
+            apply(e.data);
+        });
+    }
+
+    function apply(state: any) {
+        scenes.value = (state.scenes || []).map((scene: any) => ({
+            id: scene.id,
+            name: scene.name,
+            widgets: scene.widgets || [],
+        }));
+        active.value = state.active;
+        rules.value = {
+            enabled: !!state.rules?.enabled,
+            rules: state.rules?.rules || [],
+            debounceMs: state.rules?.debounceMs || 0,
+        };
+    }
+
+    /**
+     * Makes a scene the one the headless window shows.
+     *
+     * @param id The ID of the scene.
+     */
+    async function setActive(id: string) {
+        try {
+            await SetActiveScene(id);
+        } catch (e) {
+            console.warn(e);
+        }
+    }
+
+    /**
+     * Adds an empty scene.
+     *
+     * @param name The name of the scene.
+     */
+    async function create(name: string) {
+        try {
+            return await CreateScene(name);
+        } catch (e) {
+            console.warn(e);
+        }
+    }
+
+    /**
+     * Saves a scene's name and widgets.
+     *
+     * @param scene The scene to save.
+     */
+    async function update(scene: Scene) {
+        try {
+            await UpdateScene(JSON.parse(JSON.stringify(scene)));
+        } catch (e) {
+            console.warn(e);
+        }
+    }
+
+    /**
+     * Saves the rules that switch scene.
+     *
+     * @param value The rules to save.
+     * @returns An error message if they couldn't be saved.
+     */
+    async function setRules(value: SceneRules) {
+        try {
+            await SetSceneRules(JSON.parse(JSON.stringify(value)));
+            return '';
+        } catch (e) {
+            console.warn(e);
+            return String(e);
+        }
+    }
+
+    /**
+     * Deletes a scene, after asking the user.
+     *
+     * @param id The ID of the scene.
+     */
+    function remove(id: string) {
+        window.$dialog.confirm(async () => {
+            try {
+                await DeleteScene(id);
+            } catch (e) {
+                console.warn(e);
+            }
+        }, 'Are you sure you want to delete this scene?', 'Delete Scene');
+    }
+
+    return {
+        scenes,
+        active,
+        activeScene,
+        rules,
+        init,
+        setActive,
+        create,
+        update,
+        remove,
+        setRules
+    }
+
+})
@@ -3,8 +3,10 @@ import { ref } from 'vue';
 import { Application, Events } from '@wailsio/runtime';
 
 import { GetProp } from '../../bindings/SmashGlass/services/configservice';
+import { BroadcastSocketMessage, SendSocketMessage } from '../../bindings/SmashGlass/services/windowservice';
 
 import WSData from '@/models/WSData';
+import { isMainOverlay } from '@/utils/helpers';
 
 export const useSocketStore = defineStore('socketStore', () => {
     /* ---------- state ---------- */
@@ -17,6 +19,20 @@ export const useSocketStore = defineStore('socketStore', () => {
             const msg = JSON.parse(data.data.data);
             window.$eventBus.emit(msg.event, msg.data);
         });
+
+        if (isMainOverlay()) {
+            // Commands sent by hotkeys and the other overlays
+            Events.On('socket:send', (e: any) => {
+                send(e.data.event, e.data.data);
+            });
+        } else {
+            // Messages received by the main overlay, for the overlays on
+            // other monitors and the studio and headless windows
+            Events.On('overlay:socket', (e: any) => {
+                const data = new WSData(e.data.data);
+                window.$eventBus.emit(data.event, data.data);
+            });
+        }
     }
 
     /* ---------- actions ---------- */
@@ -24,6 +40,9 @@ export const useSocketStore = defineStore('socketStore', () => {
     async function connect() {
         //window.$helpers.log('Connecting to main app...');
 
+        // Only the main overlay connects, and passes messages on to the others
+        if (!isMainOverlay()) return;
+
         let port = 9002;
         const prop = await GetProp('Socket', 'port');
         if (prop[0]) {
@@ -61,12 +80,17 @@ export const useSocketStore = defineStore('socketStore', () => {
     }
 
     async function send(event: string, data: any) {
+        if (!isMainOverlay()) {
+            SendSocketMessage(event, data);
+            return;
+        }
         conn.value?.send(JSON.stringify({ event: event, data: data }));
     }
 
     async function onMessage(event: MessageEvent) {
         const data = new WSData(event.data);
         window.$eventBus.emit(data.event, data.data);
+        BroadcastSocketMessage(event.data);
     }
 
     /* ---------- expose ---------- */
@@ -179,9 +179,35 @@ function getKeyName(key: number) {
     return keyMap[key] || `Unknown Key (${key})`
 }
 
+/**
+ * The monitor ID of the overlay this page is in, or an empty string for
+ * the main overlay. Overlays on other monitors are opened with ?display=.
+ */
+function overlayDisplay() {
+    return new URLSearchParams(window.location.search).get('display') || '';
+}
+
+/**
+ * The kind of window this page is in: 'overlay', 'studio' or 'headless'.
+ */
+function windowMode() {
+    return new URLSearchParams(window.location.search).get('mode') || 'overlay';
+}
+
+/**
+ * Whether this page is the main overlay, which owns the socket connection,
+ * the hotkeys and the settings.
+ */
+function isMainOverlay() {
+    return windowMode() === 'overlay' && overlayDisplay() === '';
+}
+
 export {
     formatRelativeDate,
     debounce,
     log,
-    getKeyName
+    getKeyName,
+    overlayDisplay,
+    windowMode,
+    isMainOverlay
 };
\ No newline at end of file
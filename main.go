@@ -26,6 +26,10 @@ func init() {
 
 func main() {
 
+	if handled, code := runCLI(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
 	mode := "overlay"
 	serverMode := "false"
 	windowMode := "false"
@@ -42,6 +46,10 @@ func main() {
 	hotkeyService := services.NewHotkeyService()
 	discordService := services.NewDiscordService(discordClientId)
 
+	windowService := services.NewWindowService((windowMode == "true"))
+	hookService := services.NewHookService(windowService)
+	sceneService := services.NewSceneService(hotkeyService, windowService, serverService)
+
 	if serverMode == "true" {
 		port, err := strconv.Atoi(serverPort)
 		if err != nil {
@@ -51,8 +59,6 @@ func main() {
 		}
 	}
 
-	windowService := services.NewWindowService((windowMode == "true"))
-
 	var shutdownOnce sync.Once
 
 	shutdown := func(reason string) {
@@ -60,6 +66,7 @@ func main() {
 			fmt.Println("[SHUTDOWN]", reason)
 
 			hotkeyService.UnregisterAll()
+			windowService.StopWatchingDisplays()
 
 			if serverMode == "true" {
 				serverService.StopServer()
@@ -85,12 +92,13 @@ func main() {
 		Services: []application.Service{
 			application.NewService(windowService),
 			application.NewService(hotkeyService),
-			application.NewService(&services.HookService{}),
+			application.NewService(hookService),
 			application.NewService(services.NewPluginService()),
 			application.NewService(services.NewStyleService()),
 			application.NewService(configService),
 			application.NewService(discordService),
 			application.NewService(services.NewFileService()),
+			application.NewService(sceneService),
 		},
 		Assets: application.AssetOptions{
 			Handler: application.AssetFileServerFS(assets),
@@ -124,14 +132,19 @@ func main() {
 		Height:                     height,
 		ZoomControlEnabled:         true,
 		DefaultContextMenuDisabled: false,
-		AlwaysOnTop:                !(windowMode == "true"),
-		IgnoreMouseEvents:          !(windowMode == "true"),
+		Frameless:                  windowService.GetMode() == services.WindowModeBorderless,
+		AlwaysOnTop:                windowService.GetMode() == services.WindowModeOverlay,
+		IgnoreMouseEvents:          windowService.GetMode() == services.WindowModeOverlay,
 		DevToolsEnabled:            true,
 	})
 
 	services.WailsApp = app
 	services.WailsWindow = window
 
+	if err := windowService.WatchDisplays(); err != nil {
+		log.Println(err)
+	}
+
 	err := app.Run()
 	if err != nil {
 		shutdown("app.Run error")
@@ -0,0 +1,67 @@
+package services
+
+import "math"
+
+// EventDisplayChanged is emitted with a DisplayInfo when an overlay moves
+// to another monitor or the monitor it is on changes size or scale. Each
+// overlay only follows the events for itself.
+const EventDisplayChanged = "display:changed"
+
+// DisplaySize is a width and height
+type DisplaySize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// DisplayInfo describes the monitor an overlay is on. Physical sizes are
+// device pixels. Logical sizes are the CSS pixels the overlay page sees,
+// which is what widget positions are stored in, so widgets keep their
+// place on monitors with different scaling.
+type DisplayInfo struct {
+	Overlay  string      `json:"overlay"` // Monitor ID of an extra overlay, empty for the main one
+	ID       string      `json:"id"`
+	Scale    float64     `json:"scale"`
+	Physical DisplaySize `json:"physical"`
+	Logical  DisplaySize `json:"logical"`
+}
+
+// logical converts a length in device pixels to logical pixels
+func (m Monitor) logical(length int) int {
+	if m.Scale <= 0 {
+		return length
+	}
+	return int(math.Round(float64(length) / m.Scale))
+}
+
+func displayInfo(m Monitor) DisplayInfo {
+	return DisplayInfo{
+		ID:       m.ID,
+		Scale:    m.Scale,
+		Physical: DisplaySize{Width: m.Width, Height: m.Height},
+		Logical:  DisplaySize{Width: m.logical(m.Width), Height: m.logical(m.Height)},
+	}
+}
+
+// GetDisplay returns the monitor the main overlay was last moved to
+func (ws *WindowService) GetDisplay() DisplayInfo {
+	ws.displayMu.Lock()
+	defer ws.displayMu.Unlock()
+
+	return ws.display
+}
+
+// setDisplay records the monitor the overlay is on and emits
+// display:changed if it is different from before
+func (ws *WindowService) setDisplay(m Monitor) {
+	info := displayInfo(m)
+
+	ws.displayMu.Lock()
+	if ws.display == info {
+		ws.displayMu.Unlock()
+		return
+	}
+	ws.display = info
+	ws.displayMu.Unlock()
+
+	emitAppEvent(EventDisplayChanged, info)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long to wait for display changes to settle before moving the
+// overlay. Plugging in a monitor reports several changes in a row.
+const displayChangeDelay = 500 * time.Millisecond
+
+// DisplayWatcher reports when monitors are plugged in, unplugged,
+// rearranged or change resolution. Each platform provides its own;
+// fakeDisplayWatcher is used in tests.
+type DisplayWatcher interface {
+	// Start calls onChange, from its own goroutine, after every change
+	Start(onChange func()) error
+	// Stop returns once onChange will no longer be called
+	Stop()
+}
+
+// WatchDisplays starts following display changes. The overlay is moved
+// back onto its monitor, or the closest match while it is unplugged, and
+// display:changed is emitted so widgets can lay themselves out again.
+func (ws *WindowService) WatchDisplays() error {
+	ws.watchMu.Lock()
+	defer ws.watchMu.Unlock()
+
+	if ws.displayWatcher != nil {
+		return nil
+	}
+
+	if ws.newDisplayWatcher == nil {
+		ws.newDisplayWatcher = newDisplayWatcher
+	}
+
+	watcher := ws.newDisplayWatcher()
+	if err := watcher.Start(ws.displaysChanged); err != nil {
+		return fmt.Errorf("error watching displays: %w", err)
+	}
+	ws.displayWatcher = watcher
+
+	fmt.Println("Started watching displays")
+
+	return nil
+}
+
+// StopWatchingDisplays stops following display changes
+func (ws *WindowService) StopWatchingDisplays() {
+	ws.watchMu.Lock()
+	defer ws.watchMu.Unlock()
+
+	if ws.displayWatcher == nil {
+		return
+	}
+
+	ws.displayWatcher.Stop()
+	ws.displayWatcher = nil
+
+	ws.timerMu.Lock()
+	if ws.displayTimer != nil {
+		ws.displayTimer.Stop()
+		ws.displayTimer = nil
+	}
+	ws.timerMu.Unlock()
+
+	fmt.Println("Stopped watching displays")
+}
+
+// displaysChanged is called by the watcher. Changes are handled once they
+// have stopped coming for displayChangeDelay.
+func (ws *WindowService) displaysChanged() {
+	ws.timerMu.Lock()
+	defer ws.timerMu.Unlock()
+
+	if ws.displayTimer != nil {
+		ws.displayTimer.Stop()
+	}
+	ws.displayTimer = time.AfterFunc(displayChangeDelay, ws.followDisplay)
+}
+
+// followDisplay moves the overlays to wherever their monitors are now, if
+// that has changed
+func (ws *WindowService) followDisplay() {
+	if WailsWindow == nil {
+		return
+	}
+
+	ws.displayMu.Lock()
+	target := ws.target
+	ws.displayMu.Unlock()
+
+	monitors := ws.listMonitors()
+	index := resolveMonitor(monitors, target)
+	if index < 0 {
+		fmt.Println("Displays changed, but no monitors were found")
+		return
+	}
+
+	ws.moveMu.Lock()
+	if monitors[index] != ws.placed {
+		fmt.Printf("Displays changed, moving to %s\n", monitors[index].ID)
+		ws.moveMainWindowLocked(index, monitors[index])
+	}
+	ws.placeOverlaysLocked(monitors)
+	ws.moveMu.Unlock()
+
+	ws.refreshHitRegions()
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+// fakeDisplayWatcher is an in-memory DisplayWatcher for tests. Display
+// changes are simulated with Change, usually after updating a
+// fakeMonitorSource.
+type fakeDisplayWatcher struct {
+	mu       sync.Mutex
+	onChange func()
+	fail     error
+}
+
+func newFakeDisplayWatcher() *fakeDisplayWatcher {
+	return &fakeDisplayWatcher{}
+}
+
+func (w *fakeDisplayWatcher) Start(onChange func()) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.fail != nil {
+		return w.fail
+	}
+	if w.onChange != nil {
+		return fmt.Errorf("fake display watcher already started")
+	}
+
+	w.onChange = onChange
+	return nil
+}
+
+func (w *fakeDisplayWatcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onChange = nil
+}
+
+// Fail makes the next Start return err
+func (w *fakeDisplayWatcher) Fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.fail = err
+}
+
+// Change reports a display change synchronously if the watcher is running
+func (w *fakeDisplayWatcher) Change() {
+	w.mu.Lock()
+	onChange := w.onChange
+	w.mu.Unlock()
+
+	if onChange != nil {
+		onChange()
+	}
+}
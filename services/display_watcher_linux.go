@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/jezek/xgb/randr"
+)
+
+// x11DisplayWatcher follows XRandR screen, CRTC and output change events
+type x11DisplayWatcher struct {
+	display *x11Display
+	done    chan struct{}
+}
+
+func newDisplayWatcher() DisplayWatcher {
+	return &x11DisplayWatcher{}
+}
+
+func (w *x11DisplayWatcher) Start(onChange func()) error {
+	display, err := openX11Display()
+	if err != nil {
+		return err
+	}
+
+	if err := randr.Init(display.conn); err != nil {
+		display.Close()
+		return fmt.Errorf("error initializing XRandR: %w", err)
+	}
+
+	mask := uint16(randr.NotifyMaskScreenChange | randr.NotifyMaskCrtcChange | randr.NotifyMaskOutputChange)
+	if err := randr.SelectInputChecked(display.conn, display.root, mask).Check(); err != nil {
+		display.Close()
+		return fmt.Errorf("error selecting XRandR events: %w", err)
+	}
+
+	w.display = display
+	w.done = make(chan struct{})
+	go w.run(onChange)
+
+	return nil
+}
+
+func (w *x11DisplayWatcher) Stop() {
+	w.display.Close()
+	<-w.done
+}
+
+func (w *x11DisplayWatcher) run(onChange func()) {
+	defer close(w.done)
+
+	for {
+		ev, err := w.display.conn.WaitForEvent()
+		if ev == nil && err == nil {
+			return // Connection closed
+		}
+		if err != nil {
+			continue
+		}
+
+		switch ev.(type) {
+		case randr.ScreenChangeNotifyEvent, randr.NotifyEvent:
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !linux
+
+package services
+
+import "fmt"
+
+// unsupportedDisplayWatcher is used on platforms without a display watcher
+type unsupportedDisplayWatcher struct{}
+
+func newDisplayWatcher() DisplayWatcher {
+	return unsupportedDisplayWatcher{}
+}
+
+func (unsupportedDisplayWatcher) Start(onChange func()) error {
+	return fmt.Errorf("watching displays is not supported on this platform")
+}
+
+func (unsupportedDisplayWatcher) Stop() {}
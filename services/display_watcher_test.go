@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestDisplayWatcher() (*WindowService, *fakeDisplayWatcher) {
+	watcher := newFakeDisplayWatcher()
+	ws := newWindowService(WindowModeOverlay, newFakeMonitorSource(testMonitors...))
+	ws.newDisplayWatcher = func() DisplayWatcher { return watcher }
+	return ws, watcher
+}
+
+func pendingDisplayChange(ws *WindowService) bool {
+	ws.timerMu.Lock()
+	defer ws.timerMu.Unlock()
+
+	return ws.displayTimer != nil
+}
+
+func TestWatchDisplaysDebouncesChanges(t *testing.T) {
+	ws, watcher := newTestDisplayWatcher()
+
+	if err := ws.WatchDisplays(); err != nil {
+		t.Fatalf("WatchDisplays: %v", err)
+	}
+	// Watching again keeps the running watcher
+	if err := ws.WatchDisplays(); err != nil {
+		t.Fatalf("WatchDisplays again: %v", err)
+	}
+
+	if pendingDisplayChange(ws) {
+		t.Fatal("a change is pending before any were reported")
+	}
+
+	watcher.Change()
+	watcher.Change()
+	if !pendingDisplayChange(ws) {
+		t.Fatal("the change wasn't scheduled")
+	}
+
+	ws.StopWatchingDisplays()
+	if pendingDisplayChange(ws) {
+		t.Fatal("stopping left a change pending")
+	}
+
+	// Changes after stopping are ignored
+	watcher.Change()
+	if pendingDisplayChange(ws) {
+		t.Fatal("a change was scheduled after stopping")
+	}
+}
+
+func TestWatchDisplaysError(t *testing.T) {
+	ws, watcher := newTestDisplayWatcher()
+	watcher.Fail(errors.New("no display"))
+
+	if err := ws.WatchDisplays(); err == nil {
+		t.Fatal("WatchDisplays succeeded")
+	}
+
+	// Nothing is running, so stopping does nothing
+	ws.StopWatchingDisplays()
+}
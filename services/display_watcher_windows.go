@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	registerClassEx        = user32.NewProc("RegisterClassExW")
+	createWindowEx         = user32.NewProc("CreateWindowExW")
+	destroyWindow          = user32.NewProc("DestroyWindow")
+	defWindowProc          = user32.NewProc("DefWindowProcW")
+	activeDisplayWatcher   *winDisplayWatcher
+	activeDisplayWatcherMu sync.Mutex
+	displayWindowClass     = syscall.StringToUTF16Ptr("SmashGlassDisplayWatcher")
+	registerDisplayClass   sync.Once
+	registerDisplayErr     error
+)
+
+const WM_DISPLAYCHANGE = 0x007E
+
+// WNDCLASSEX is WNDCLASSEXW
+type WNDCLASSEX struct {
+	CbSize        uint32
+	Style         uint32
+	LpfnWndProc   uintptr
+	CbClsExtra    int32
+	CbWndExtra    int32
+	HInstance     windows.Handle
+	HIcon         windows.Handle
+	HCursor       windows.Handle
+	HbrBackground windows.Handle
+	LpszMenuName  *uint16
+	LpszClassName *uint16
+	HIconSm       windows.Handle
+}
+
+// displayWindowProc is created once, as Windows callbacks are never freed.
+// It forwards WM_DISPLAYCHANGE to the running watcher.
+var displayWindowProc = syscall.NewCallback(func(hwnd, msg, wParam, lParam uintptr) uintptr {
+	if msg == WM_DISPLAYCHANGE {
+		activeDisplayWatcherMu.Lock()
+		w := activeDisplayWatcher
+		activeDisplayWatcherMu.Unlock()
+
+		if w != nil {
+			w.onChange()
+		}
+	}
+
+	ret, _, _ := defWindowProc.Call(hwnd, msg, wParam, lParam)
+	return ret
+})
+
+// winDisplayWatcher listens for WM_DISPLAYCHANGE, which is only sent to
+// top-level windows, on a hidden window of its own
+type winDisplayWatcher struct {
+	onChange func()
+	threadID uint32
+	done     chan struct{}
+}
+
+func newDisplayWatcher() DisplayWatcher {
+	return &winDisplayWatcher{}
+}
+
+func (w *winDisplayWatcher) Start(onChange func()) error {
+	activeDisplayWatcherMu.Lock()
+	defer activeDisplayWatcherMu.Unlock()
+
+	if activeDisplayWatcher != nil {
+		return fmt.Errorf("displays are already being watched")
+	}
+
+	w.onChange = onChange
+	w.done = make(chan struct{})
+	started := make(chan error)
+
+	go w.run(started)
+
+	if err := <-started; err != nil {
+		return err
+	}
+
+	activeDisplayWatcher = w
+	return nil
+}
+
+func (w *winDisplayWatcher) Stop() {
+	activeDisplayWatcherMu.Lock()
+	if activeDisplayWatcher == w {
+		activeDisplayWatcher = nil
+	}
+	activeDisplayWatcherMu.Unlock()
+
+	postThreadMessage.Call(uintptr(w.threadID), WM_QUIT, 0, 0)
+	<-w.done
+}
+
+// run creates the hidden window and pumps messages on a locked OS thread
+// until WM_QUIT is posted to it
+func (w *winDisplayWatcher) run(started chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(w.done)
+
+	w.threadID = windows.GetCurrentThreadId()
+
+	var instance windows.Handle
+	windows.GetModuleHandleEx(0, nil, &instance)
+
+	registerDisplayClass.Do(func() {
+		wc := WNDCLASSEX{
+			LpfnWndProc:   displayWindowProc,
+			HInstance:     instance,
+			LpszClassName: displayWindowClass,
+		}
+		wc.CbSize = uint32(unsafe.Sizeof(wc))
+		if atom, _, err := registerClassEx.Call(uintptr(unsafe.Pointer(&wc))); atom == 0 {
+			registerDisplayErr = fmt.Errorf("RegisterClassEx failed: %v", err)
+		}
+	})
+	if registerDisplayErr != nil {
+		started <- registerDisplayErr
+		return
+	}
+
+	// Never shown, but not a message-only window, which misses broadcasts
+	hwnd, _, err := createWindowEx.Call(0, uintptr(unsafe.Pointer(displayWindowClass)), 0, 0,
+		0, 0, 0, 0, 0, 0, uintptr(instance), 0)
+	if hwnd == 0 {
+		started <- fmt.Errorf("CreateWindowEx failed: %v", err)
+		return
+	}
+	defer destroyWindow.Call(hwnd)
+
+	started <- nil
+
+	var msg struct {
+		hwnd    uintptr
+		message uint32
+		wParam  uintptr
+		lParam  uintptr
+		time    uint32
+		pt      struct{ x, y int32 }
+	}
+	for {
+		ret, _, _ := getMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			return
+		}
+		dispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
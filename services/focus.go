@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EventFocusChanged is emitted with a FocusInfo whenever another window
+// comes to the foreground
+const EventFocusChanged = "focus:changed"
+
+// FocusInfo describes the foreground window
+type FocusInfo struct {
+	Handle  uint64 `json:"handle"` // Same as WindowInfo.Handle
+	Title   string `json:"title"`
+	Process string `json:"process"` // Executable name, e.g. "game.exe"
+	PID     uint32 `json:"pid"`
+	Target  bool   `json:"target"` // The title contains the name passed to StartWatchingFocus
+}
+
+// FocusWatcher reports foreground window changes across all processes.
+// Each platform provides its own; fakeFocusWatcher is used in tests.
+type FocusWatcher interface {
+	// Start calls onChange, from its own goroutine, with the current
+	// foreground window and then every time it changes
+	Start(onChange func(FocusInfo)) error
+	// Stop returns once onChange will no longer be called
+	Stop()
+}
+
+// focusTracker turns FocusWatcher callbacks into focus:changed events
+type focusTracker struct {
+	newWatcher func() FocusWatcher
+	emit       func(name string, data interface{})
+
+	control sync.Mutex // Serializes starting and stopping the watcher
+	watcher FocusWatcher
+
+	mu     sync.Mutex // Guards target and last, which the watcher reads
+	target string
+	last   FocusInfo
+}
+
+// newHookService creates a HookService with the given focus watcher and
+// event sink, so that tests can swap both out. The zero HookService uses
+// the platform's watcher.
+func newHookService(newWatcher func() FocusWatcher, emit func(name string, data interface{})) *HookService {
+	return &HookService{
+		focus: focusTracker{newWatcher: newWatcher, emit: emit},
+	}
+}
+
+// StartWatchingFocus starts emitting focus:changed events. Events for
+// windows whose title contains windowName are flagged as the target.
+func (hs *HookService) StartWatchingFocus(windowName string) error {
+	f := &hs.focus
+
+	f.control.Lock()
+	defer f.control.Unlock()
+
+	f.stopLocked()
+
+	if f.newWatcher == nil {
+		f.newWatcher = newFocusWatcher
+	}
+
+	f.mu.Lock()
+	if f.emit == nil {
+		f.emit = emitAppEvent
+	}
+	f.target = strings.ToLower(windowName)
+	f.last = FocusInfo{}
+	f.mu.Unlock()
+
+	watcher := f.newWatcher()
+	onChange := func(info FocusInfo) {
+		if info, changed := f.changed(info); changed {
+			hs.applyFocusRules(info)
+		}
+	}
+
+	if err := watcher.Start(onChange); err != nil {
+		return fmt.Errorf("error watching focus: %w", err)
+	}
+	f.watcher = watcher
+
+	fmt.Printf("Started watching focus for window: %s\n", windowName)
+
+	return nil
+}
+
+// StopWatchingFocus stops emitting focus:changed events
+func (hs *HookService) StopWatchingFocus() {
+	f := &hs.focus
+
+	f.control.Lock()
+	defer f.control.Unlock()
+
+	f.stopLocked()
+}
+
+// watchingFocus reports whether a focus watcher is running
+func (hs *HookService) watchingFocus() bool {
+	hs.focus.control.Lock()
+	defer hs.focus.control.Unlock()
+
+	return hs.focus.watcher != nil
+}
+
+// stopLocked stops the watcher. f.control must be held.
+func (f *focusTracker) stopLocked() {
+	if f.watcher == nil {
+		return
+	}
+
+	f.watcher.Stop()
+	f.watcher = nil
+
+	fmt.Println("Stopped watching focus")
+}
+
+// changed is called by the watcher. Repeated reports of the same window
+// are dropped, otherwise the window is emitted and returned.
+func (f *focusTracker) changed(info FocusInfo) (FocusInfo, bool) {
+	f.mu.Lock()
+	if f.target != "" {
+		info.Target = strings.Contains(strings.ToLower(info.Title), f.target)
+	}
+	if info == f.last {
+		f.mu.Unlock()
+		return info, false
+	}
+	f.last = info
+	emit := f.emit
+	f.mu.Unlock()
+
+	emit(EventFocusChanged, info)
+
+	return info, true
+}
+
+// current returns the last foreground window reported
+func (f *focusTracker) current() FocusInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.last
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+// fakeFocusWatcher is an in-memory FocusWatcher for tests. Focus changes
+// are simulated with Focus.
+type fakeFocusWatcher struct {
+	mu       sync.Mutex
+	onChange func(FocusInfo)
+	current  FocusInfo
+	fail     error
+	pending  sync.WaitGroup // The initial report Start makes from its own goroutine
+}
+
+// newFakeFocusWatcher returns a watcher whose foreground window starts as
+// current
+func newFakeFocusWatcher(current FocusInfo) *fakeFocusWatcher {
+	return &fakeFocusWatcher{current: current}
+}
+
+func (w *fakeFocusWatcher) Start(onChange func(FocusInfo)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.fail != nil {
+		return w.fail
+	}
+	if w.onChange != nil {
+		return fmt.Errorf("fake focus watcher already started")
+	}
+
+	w.onChange = onChange
+	current := w.current
+	w.pending.Add(1)
+	go func() {
+		defer w.pending.Done()
+		onChange(current)
+	}()
+
+	return nil
+}
+
+func (w *fakeFocusWatcher) Stop() {
+	w.mu.Lock()
+	w.onChange = nil
+	w.mu.Unlock()
+
+	// Like the real watchers, don't return while onChange may still run
+	w.pending.Wait()
+}
+
+// Fail makes the next Start return err
+func (w *fakeFocusWatcher) Fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.fail = err
+}
+
+// Focus brings a window to the foreground, reporting it synchronously if
+// the watcher is running
+func (w *fakeFocusWatcher) Focus(info FocusInfo) {
+	w.mu.Lock()
+	w.current = info
+	onChange := w.onChange
+	w.mu.Unlock()
+
+	if onChange != nil {
+		onChange(info)
+	}
+}
+
+// Running reports whether the watcher has been started and not stopped
+func (w *fakeFocusWatcher) Running() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.onChange != nil
+}
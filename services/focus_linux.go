@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// x11FocusWatcher follows the foreground window through the
+// _NET_ACTIVE_WINDOW property that EWMH window managers keep on the root
+// window
+type x11FocusWatcher struct {
+	display *x11Display
+	done    chan struct{}
+}
+
+func newFocusWatcher() FocusWatcher {
+	return &x11FocusWatcher{}
+}
+
+func (w *x11FocusWatcher) Start(onChange func(FocusInfo)) error {
+	display, err := openX11Display("_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return err
+	}
+
+	// Ask for PropertyNotify events on the root window
+	err = xproto.ChangeWindowAttributesChecked(display.conn, display.root, xproto.CwEventMask,
+		[]uint32{xproto.EventMaskPropertyChange}).Check()
+	if err != nil {
+		display.Close()
+		return fmt.Errorf("error watching root window: %w", err)
+	}
+
+	w.display = display
+	w.done = make(chan struct{})
+	go w.run(onChange)
+
+	return nil
+}
+
+func (w *x11FocusWatcher) Stop() {
+	w.display.Close()
+	<-w.done
+}
+
+func (w *x11FocusWatcher) run(onChange func(FocusInfo)) {
+	defer close(w.done)
+
+	onChange(w.activeWindow())
+
+	for {
+		ev, err := w.display.conn.WaitForEvent()
+		if ev == nil && err == nil {
+			return // Connection closed
+		}
+		if err != nil {
+			continue
+		}
+
+		if pe, ok := ev.(xproto.PropertyNotifyEvent); ok && pe.Atom == w.display.atoms["_NET_ACTIVE_WINDOW"] {
+			onChange(w.activeWindow())
+		}
+	}
+}
+
+// activeWindow looks up the title and owning process of the active window
+func (w *x11FocusWatcher) activeWindow() FocusInfo {
+	return w.display.activeWindow()
+}
+
+// foregroundWindow looks up the window that is active right now
+func foregroundWindow() (FocusInfo, error) {
+	d, err := openX11Display("_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return FocusInfo{}, err
+	}
+	defer d.Close()
+
+	return d.activeWindow(), nil
+}
+
+// activeWindow reads _NET_ACTIVE_WINDOW and describes the window it names
+func (d *x11Display) activeWindow() FocusInfo {
+	value := d.property(d.root, d.atoms["_NET_ACTIVE_WINDOW"], xproto.AtomWindow)
+	if len(value) < 4 {
+		return FocusInfo{}
+	}
+	win := xproto.Window(xgb.Get32(value))
+	if win == 0 {
+		return FocusInfo{}
+	}
+
+	return d.focusInfo(win)
+}
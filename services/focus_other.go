@@ -0,0 +1,24 @@
+//go:build !windows && !linux
+
+package services
+
+import "fmt"
+
+// unsupportedFocusWatcher is used on platforms without a focus watcher
+type unsupportedFocusWatcher struct{}
+
+func newFocusWatcher() FocusWatcher {
+	return unsupportedFocusWatcher{}
+}
+
+func (unsupportedFocusWatcher) Start(onChange func(FocusInfo)) error {
+	return fmt.Errorf("focus watching is not supported on this platform")
+}
+
+func (unsupportedFocusWatcher) Stop() {}
+
+// foregroundWindow reports that the foreground window can't be looked up
+// on this platform
+func foregroundWindow() (FocusInfo, error) {
+	return FocusInfo{}, fmt.Errorf("finding the foreground window is not supported on this platform")
+}
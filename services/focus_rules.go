@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// What a FocusRule matches against
+const (
+	FocusMatchTitle   = "title"   // The title contains Pattern
+	FocusMatchRegex   = "regex"   // The title matches the regular expression Pattern
+	FocusMatchProcess = "process" // The process name is Pattern, with or without ".exe"
+	FocusMatchWindow  = "window"  // The window handle from GetWindows is Pattern
+)
+
+// What to do with the overlay when a FocusRule matches
+const (
+	FocusActionShow = "show"
+	FocusActionHide = "hide"
+	FocusActionDim  = "dim"
+)
+
+const defaultDimOpacity = 0.3
+
+// FocusRule shows, hides or dims the overlay when a matching window comes
+// to the foreground
+type FocusRule struct {
+	Match   string `json:"match"`
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+}
+
+// FocusRules decides how the overlay looks for each foreground window.
+// Rules are checked in order and the first match wins; Default applies
+// when none match.
+type FocusRules struct {
+	Enabled    bool        `json:"enabled"`
+	Rules      []FocusRule `json:"rules"`
+	Default    string      `json:"default"`
+	DimOpacity float64     `json:"dimOpacity"` // Opacity used by the dim action
+}
+
+// focusRule is a FocusRule ready to be matched
+type focusRule struct {
+	FocusRule
+	pattern string
+	re      *regexp.Regexp
+}
+
+// focusRuleSet holds the rules applied to focus changes
+type focusRuleSet struct {
+	setOpacity func(float64)
+
+	mu         sync.Mutex
+	enabled    bool
+	rules      []focusRule
+	fallback   string
+	dimOpacity float64
+}
+
+// NewHookService creates a HookService that fades the overlay through
+// ws according to the focus rules
+func NewHookService(ws *WindowService) *HookService {
+	hs := &HookService{}
+	hs.rules.setOpacity = ws.SetOpacity
+	return hs
+}
+
+// SetFocusRules replaces the focus rules and applies them to the current
+// foreground window. Focus is watched from then on if it isn't already.
+func (hs *HookService) SetFocusRules(rules FocusRules) error {
+	compiled, err := compileFocusRules(rules)
+	if err != nil {
+		return err
+	}
+
+	if rules.Default == "" {
+		rules.Default = FocusActionShow
+	}
+	if rules.DimOpacity <= 0 || rules.DimOpacity > 1 {
+		rules.DimOpacity = defaultDimOpacity
+	}
+
+	r := &hs.rules
+	r.mu.Lock()
+	r.enabled = rules.Enabled
+	r.rules = compiled
+	r.fallback = rules.Default
+	r.dimOpacity = rules.DimOpacity
+	r.mu.Unlock()
+
+	if !rules.Enabled {
+		r.apply(1)
+		return nil
+	}
+
+	if !hs.watchingFocus() {
+		return hs.StartWatchingFocus("")
+	}
+
+	hs.applyFocusRules(hs.focus.current())
+
+	return nil
+}
+
+// applyFocusRules fades the overlay for the given foreground window. The
+// overlay's own windows are ignored, so opening the settings doesn't hide
+// it.
+func (hs *HookService) applyFocusRules(info FocusInfo) {
+	if info.PID == uint32(os.Getpid()) {
+		return
+	}
+
+	r := &hs.rules
+	r.mu.Lock()
+	if !r.enabled {
+		r.mu.Unlock()
+		return
+	}
+	action := r.fallback
+	for _, rule := range r.rules {
+		if rule.matches(info) {
+			action = rule.Action
+			break
+		}
+	}
+	dim := r.dimOpacity
+	r.mu.Unlock()
+
+	switch action {
+	case FocusActionHide:
+		r.apply(0)
+	case FocusActionDim:
+		r.apply(dim)
+	default:
+		r.apply(1)
+	}
+}
+
+func (r *focusRuleSet) apply(opacity float64) {
+	if r.setOpacity != nil {
+		r.setOpacity(opacity)
+	}
+}
+
+// compileFocusRules checks each rule and compiles regular expressions
+func compileFocusRules(rules FocusRules) ([]focusRule, error) {
+	switch rules.Default {
+	case "", FocusActionShow, FocusActionHide, FocusActionDim:
+	default:
+		return nil, fmt.Errorf("unknown focus action %q", rules.Default)
+	}
+
+	compiled := make([]focusRule, 0, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		switch rule.Action {
+		case FocusActionShow, FocusActionHide, FocusActionDim:
+		default:
+			return nil, fmt.Errorf("focus rule %d: unknown action %q", i+1, rule.Action)
+		}
+
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return nil, fmt.Errorf("focus rule %d has no pattern", i+1)
+		}
+
+		c := focusRule{FocusRule: rule}
+		switch rule.Match {
+		case FocusMatchTitle:
+			c.pattern = strings.ToLower(rule.Pattern)
+		case FocusMatchProcess:
+			c.pattern = strings.TrimSuffix(strings.ToLower(rule.Pattern), ".exe")
+		case FocusMatchWindow:
+			if _, err := strconv.ParseUint(rule.Pattern, 10, 64); err != nil {
+				return nil, fmt.Errorf("focus rule %d: invalid window handle %q", i+1, rule.Pattern)
+			}
+			c.pattern = rule.Pattern
+		case FocusMatchRegex:
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error compiling focus rule %d: %w", i+1, err)
+			}
+			c.re = re
+		default:
+			return nil, fmt.Errorf("focus rule %d: unknown match %q", i+1, rule.Match)
+		}
+
+		compiled = append(compiled, c)
+	}
+
+	return compiled, nil
+}
+
+func (r focusRule) matches(info FocusInfo) bool {
+	switch r.Match {
+	case FocusMatchTitle:
+		return strings.Contains(strings.ToLower(info.Title), r.pattern)
+	case FocusMatchProcess:
+		return info.Process != "" && strings.TrimSuffix(strings.ToLower(info.Process), ".exe") == r.pattern
+	case FocusMatchRegex:
+		return r.re.MatchString(info.Title)
+	case FocusMatchWindow:
+		return strconv.FormatUint(info.Handle, 10) == r.pattern
+	}
+	return false
+}
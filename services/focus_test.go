@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestHookService(watcher *fakeFocusWatcher) (*HookService, *eventRecorder) {
+	events := &eventRecorder{}
+	hs := newHookService(func() FocusWatcher { return watcher }, events.emit)
+	return hs, events
+}
+
+func TestWatchingFocusEmitsChanges(t *testing.T) {
+	watcher := newFakeFocusWatcher(FocusInfo{Handle: 1, Title: "Desktop", Process: "explorer.exe", PID: 100})
+	hs, events := newTestHookService(watcher)
+
+	if err := hs.StartWatchingFocus("street fighter"); err != nil {
+		t.Fatalf("StartWatchingFocus: %v", err)
+	}
+	defer hs.StopWatchingFocus()
+
+	if !watcher.Running() {
+		t.Fatal("the watcher wasn't started")
+	}
+
+	// The current window is reported when watching starts
+	first := events.waitFor(t, EventFocusChanged, 1)[0].(FocusInfo)
+	if first.Title != "Desktop" || first.Target {
+		t.Fatalf("first event = %+v, want Desktop, not the target", first)
+	}
+
+	game := FocusInfo{Handle: 2, Title: "Street Fighter 6", Process: "StreetFighter6.exe", PID: 200}
+	watcher.Focus(game)
+	watcher.Focus(game) // Repeats are dropped
+
+	changes := events.waitFor(t, EventFocusChanged, 2)
+	if len(changes) != 2 {
+		t.Fatalf("emitted %d events, want 2", len(changes))
+	}
+	if info := changes[1].(FocusInfo); info.Handle != 2 || !info.Target {
+		t.Fatalf("second event = %+v, want the game as the target", info)
+	}
+}
+
+func TestStopWatchingFocus(t *testing.T) {
+	watcher := newFakeFocusWatcher(FocusInfo{Handle: 1, Title: "Desktop"})
+	hs, events := newTestHookService(watcher)
+
+	if err := hs.StartWatchingFocus(""); err != nil {
+		t.Fatalf("StartWatchingFocus: %v", err)
+	}
+	events.waitFor(t, EventFocusChanged, 1)
+
+	hs.StopWatchingFocus()
+	if watcher.Running() || hs.watchingFocus() {
+		t.Fatal("the watcher is still running")
+	}
+
+	watcher.Focus(FocusInfo{Handle: 2, Title: "Game"})
+	if n := len(events.named(EventFocusChanged)); n != 1 {
+		t.Fatalf("emitted %d events after stopping, want 1", n)
+	}
+}
+
+func TestStartWatchingFocusError(t *testing.T) {
+	watcher := newFakeFocusWatcher(FocusInfo{})
+	watcher.Fail(errors.New("no display"))
+	hs, _ := newTestHookService(watcher)
+
+	if err := hs.StartWatchingFocus(""); err == nil {
+		t.Fatal("StartWatchingFocus succeeded")
+	}
+	if hs.watchingFocus() {
+		t.Fatal("watching focus after a failed start")
+	}
+}
+
+func TestFocusRulesSetOpacity(t *testing.T) {
+	watcher := newFakeFocusWatcher(FocusInfo{Handle: 1, Title: "Desktop", Process: "explorer.exe"})
+	hs, _ := newTestHookService(watcher)
+
+	var mu sync.Mutex
+	var opacities []float64
+	hs.rules.setOpacity = func(opacity float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		opacities = append(opacities, opacity)
+	}
+	last := func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(opacities) == 0 {
+			return -1
+		}
+		return opacities[len(opacities)-1]
+	}
+
+	// Setting enabled rules starts watching focus
+	err := hs.SetFocusRules(FocusRules{
+		Enabled: true,
+		Rules: []FocusRule{
+			{Match: FocusMatchProcess, Pattern: "StreetFighter6", Action: FocusActionShow},
+			{Match: FocusMatchTitle, Pattern: "discord", Action: FocusActionDim},
+		},
+		Default:    FocusActionHide,
+		DimOpacity: 0.5,
+	})
+	if err != nil {
+		t.Fatalf("SetFocusRules: %v", err)
+	}
+	defer hs.StopWatchingFocus()
+
+	waitUntil(t, "the desktop to hide the overlay", func() bool { return last() == 0 })
+
+	watcher.Focus(FocusInfo{Handle: 2, Title: "Street Fighter 6", Process: "StreetFighter6.exe"})
+	if got := last(); got != 1 {
+		t.Fatalf("opacity for the game = %v, want 1", got)
+	}
+
+	watcher.Focus(FocusInfo{Handle: 3, Title: "#general - Discord", Process: "Discord.exe"})
+	if got := last(); got != 0.5 {
+		t.Fatalf("opacity for Discord = %v, want 0.5", got)
+	}
+
+	if err := hs.SetFocusRules(FocusRules{Enabled: false}); err != nil {
+		t.Fatalf("SetFocusRules: %v", err)
+	}
+	if got := last(); got != 1 {
+		t.Fatalf("opacity with the rules off = %v, want 1", got)
+	}
+}
+
+func TestSetFocusRulesInvalid(t *testing.T) {
+	hs, _ := newTestHookService(newFakeFocusWatcher(FocusInfo{}))
+
+	for _, rules := range []FocusRules{
+		{Enabled: true, Default: "fade"},
+		{Enabled: true, Rules: []FocusRule{{Match: FocusMatchTitle, Pattern: "game", Action: "blink"}}},
+		{Enabled: true, Rules: []FocusRule{{Match: FocusMatchTitle, Pattern: " ", Action: FocusActionHide}}},
+		{Enabled: true, Rules: []FocusRule{{Match: FocusMatchRegex, Pattern: "(", Action: FocusActionHide}}},
+		{Enabled: true, Rules: []FocusRule{{Match: FocusMatchWindow, Pattern: "abc", Action: FocusActionHide}}},
+	} {
+		if err := hs.SetFocusRules(rules); err == nil {
+			t.Errorf("SetFocusRules(%+v) succeeded", rules)
+		}
+	}
+
+	if hs.watchingFocus() {
+		t.Fatal("invalid rules started watching focus")
+	}
+}
+
+func TestStopWatchingFocusWaitsForInitialReport(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		watcher := newFakeFocusWatcher(FocusInfo{Handle: 1, Title: "Desktop"})
+		hs, events := newTestHookService(watcher)
+
+		if err := hs.StartWatchingFocus(""); err != nil {
+			t.Fatalf("StartWatchingFocus: %v", err)
+		}
+		hs.StopWatchingFocus()
+
+		// Nothing may be reported once stopping has returned
+		before := len(events.named(EventFocusChanged))
+		time.Sleep(time.Millisecond)
+		if after := len(events.named(EventFocusChanged)); after != before {
+			t.Fatalf("emitted %d events after stopping", after-before)
+		}
+	}
+}
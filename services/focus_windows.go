@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	setWinEventHook      = user32.NewProc("SetWinEventHook")
+	unhookWinEvent       = user32.NewProc("UnhookWinEvent")
+	getMessage           = user32.NewProc("GetMessageW")
+	dispatchMessage      = user32.NewProc("DispatchMessageW")
+	postThreadMessage    = user32.NewProc("PostThreadMessageW")
+	getForegroundWindow  = user32.NewProc("GetForegroundWindow")
+	activeFocusWatcher   *winFocusWatcher
+	activeFocusWatcherMu sync.Mutex
+)
+
+const (
+	EVENT_SYSTEM_FOREGROUND = 0x0003
+	WINEVENT_OUTOFCONTEXT   = 0x0000
+	WM_QUIT                 = 0x0012
+)
+
+// focusEventCallback is created once, as Windows callbacks are never freed.
+// It forwards to the running watcher.
+var focusEventCallback = syscall.NewCallback(func(hook, event, hwnd, idObject, idChild, thread, time uintptr) uintptr {
+	activeFocusWatcherMu.Lock()
+	w := activeFocusWatcher
+	activeFocusWatcherMu.Unlock()
+
+	if w != nil && event == EVENT_SYSTEM_FOREGROUND {
+		w.onChange(windowFocusInfo(hwnd))
+	}
+	return 0
+})
+
+// winFocusWatcher follows the foreground window with SetWinEventHook. The
+// hook is out of context, so it sees every process, and its events are
+// delivered to the message loop of the thread that installed it.
+type winFocusWatcher struct {
+	onChange func(FocusInfo)
+	threadID uint32
+	done     chan struct{}
+}
+
+func newFocusWatcher() FocusWatcher {
+	return &winFocusWatcher{}
+}
+
+func (w *winFocusWatcher) Start(onChange func(FocusInfo)) error {
+	activeFocusWatcherMu.Lock()
+	defer activeFocusWatcherMu.Unlock()
+
+	if activeFocusWatcher != nil {
+		return fmt.Errorf("focus is already being watched")
+	}
+
+	w.onChange = onChange
+	w.done = make(chan struct{})
+	started := make(chan error)
+
+	go w.run(started)
+
+	if err := <-started; err != nil {
+		return err
+	}
+
+	activeFocusWatcher = w
+	return nil
+}
+
+func (w *winFocusWatcher) Stop() {
+	activeFocusWatcherMu.Lock()
+	if activeFocusWatcher == w {
+		activeFocusWatcher = nil
+	}
+	activeFocusWatcherMu.Unlock()
+
+	postThreadMessage.Call(uintptr(w.threadID), WM_QUIT, 0, 0)
+	<-w.done
+}
+
+// run installs the hook and pumps messages on a locked OS thread until
+// WM_QUIT is posted to it
+func (w *winFocusWatcher) run(started chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(w.done)
+
+	w.threadID = windows.GetCurrentThreadId()
+
+	hook, _, err := setWinEventHook.Call(
+		EVENT_SYSTEM_FOREGROUND,
+		EVENT_SYSTEM_FOREGROUND,
+		0,
+		focusEventCallback,
+		0,
+		0,
+		WINEVENT_OUTOFCONTEXT,
+	)
+	if hook == 0 {
+		started <- fmt.Errorf("SetWinEventHook failed: %v", err)
+		return
+	}
+	defer unhookWinEvent.Call(hook)
+
+	started <- nil
+
+	// Report the window that has focus right now
+	fg, _, _ := getForegroundWindow.Call()
+	if fg != 0 {
+		w.onChange(windowFocusInfo(fg))
+	}
+
+	var msg struct {
+		hwnd    uintptr
+		message uint32
+		wParam  uintptr
+		lParam  uintptr
+		time    uint32
+		pt      struct{ x, y int32 }
+	}
+	for {
+		ret, _, _ := getMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			return
+		}
+		dispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
+
+// foregroundWindow looks up the window that is in the foreground right now
+func foregroundWindow() (FocusInfo, error) {
+	hwnd, _, _ := getForegroundWindow.Call()
+	if hwnd == 0 {
+		return FocusInfo{}, fmt.Errorf("no foreground window")
+	}
+	return windowFocusInfo(hwnd), nil
+}
+
+// windowFocusInfo looks up the title and owning process of a window
+func windowFocusInfo(hwnd uintptr) FocusInfo {
+	info := FocusInfo{Handle: uint64(hwnd)}
+
+	textLen, _, _ := getWindowTextLength.Call(hwnd)
+	if textLen > 0 {
+		buf := make([]uint16, textLen+1)
+		getWindowText.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), textLen+1)
+		info.Title = syscall.UTF16ToString(buf)
+	}
+
+	windows.GetWindowThreadProcessId(windows.HWND(hwnd), &info.PID)
+	if info.PID == 0 {
+		return info
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, info.PID)
+	if err != nil {
+		return info
+	}
+	defer windows.CloseHandle(process)
+
+	buf := make([]uint16, windows.MAX_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(process, 0, &buf[0], &size); err == nil {
+		info.Process = filepath.Base(syscall.UTF16ToString(buf[:size]))
+	}
+
+	return info
+}
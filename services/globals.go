@@ -10,3 +10,16 @@ var (
 	StudioWindow   *application.WebviewWindow // Handle to the Stream Studio window
 	HeadlessWindow *application.WebviewWindow // Handle to headless overlay window
 )
+
+// emitAppEvent sends a custom event to the frontend. It does nothing until
+// the application has been created.
+func emitAppEvent(name string, data interface{}) {
+	if WailsApp == nil {
+		return
+	}
+
+	WailsApp.Event.EmitEvent(&application.CustomEvent{
+		Name: name,
+		Data: data,
+	})
+}
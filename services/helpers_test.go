@@ -0,0 +1,67 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordedEvent is an event passed to an eventRecorder
+type recordedEvent struct {
+	Name string
+	Data interface{}
+}
+
+// eventRecorder stands in for emitAppEvent in tests
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+func (r *eventRecorder) emit(name string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, recordedEvent{Name: name, Data: data})
+}
+
+// named returns the data of every event emitted with a name so far
+func (r *eventRecorder) named(name string) []interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var data []interface{}
+	for _, e := range r.events {
+		if e.Name == name {
+			data = append(data, e.Data)
+		}
+	}
+	return data
+}
+
+// waitFor waits until count events with a name have been emitted and
+// returns their data
+func (r *eventRecorder) waitFor(t *testing.T, name string, count int) []interface{} {
+	t.Helper()
+
+	var data []interface{}
+	waitUntil(t, name, func() bool {
+		data = r.named(name)
+		return len(data) >= count
+	})
+	return data
+}
+
+// waitUntil polls done until it returns true, failing the test if it takes
+// longer than a couple of seconds
+func waitUntil(t *testing.T, what string, done func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
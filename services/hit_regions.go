@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// HitRegion is an area of an overlay, in CSS pixels from its top left
+// corner, that takes the mouse while the rest of the overlay lets it
+// through to the game
+type HitRegion struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// SetHitRegions sets the areas of an overlay that take the mouse while it
+// is blurred, such as a poll's buttons. An empty ID is the main overlay.
+// Focusing the overlay gives it the whole mouse until it is blurred again.
+func (ws *WindowService) SetHitRegions(id string, regions []HitRegion) error {
+	ws.hitMu.Lock()
+	if len(regions) == 0 {
+		delete(ws.hitRegions, id)
+	} else {
+		ws.hitRegions[id] = regions
+	}
+	ws.hitMu.Unlock()
+
+	return ws.applyHitRegions()
+}
+
+// refreshHitRegions applies the hit regions again after the overlays have
+// been moved. ws.moveMu must not be held.
+func (ws *WindowService) refreshHitRegions() {
+	if err := ws.applyHitRegions(); err != nil {
+		fmt.Println("Error setting hit regions:", err)
+	}
+}
+
+// setFocused records whether the overlays take the whole mouse and updates
+// their hit regions to match
+func (ws *WindowService) setFocused(focused bool) {
+	ws.hitMu.Lock()
+	ws.focused = focused
+	ws.hitMu.Unlock()
+
+	ws.refreshHitRegions()
+}
+
+// applyHitRegions passes the hit regions of each overlay to the platform.
+// They only apply while the overlays are click-through, otherwise the
+// whole window takes the mouse.
+func (ws *WindowService) applyHitRegions() error {
+	ws.hitMu.Lock()
+	defer ws.hitMu.Unlock()
+
+	passthrough := ws.overlayMode() && !ws.focused
+
+	type target struct {
+		window *application.WebviewWindow
+		placed Monitor
+	}
+	targets := map[string]target{}
+
+	ws.moveMu.Lock()
+	if WailsWindow != nil && ws.placed.ID != "" {
+		targets[""] = target{WailsWindow, ws.placed}
+	}
+	for id, o := range ws.overlays {
+		if o.shown {
+			targets[id] = target{o.window, o.placed}
+		}
+	}
+	ws.moveMu.Unlock()
+
+	var errs []error
+	for id, t := range targets {
+		var rects []image.Rectangle
+		if passthrough {
+			rects = hitRects(ws.hitRegions[id], t.placed.Scale)
+		}
+
+		// Windows that never had regions have nothing to undo
+		if len(rects) == 0 && !ws.hitShaped[id] {
+			continue
+		}
+
+		if err := setHitRegions(t.window, t.placed, rects); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ws.hitShaped[id] = len(rects) > 0
+	}
+
+	return errors.Join(errs...)
+}
+
+// hitRects converts hit regions to device pixels. The result is never nil,
+// as nil gives the window the whole mouse.
+func hitRects(regions []HitRegion, scale float64) []image.Rectangle {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	rects := make([]image.Rectangle, 0, len(regions))
+	for _, r := range regions {
+		// image.Rect would swap the corners of a negative size
+		if r.Width <= 0 || r.Height <= 0 {
+			continue
+		}
+
+		rect := image.Rect(
+			int(math.Floor(r.X*scale)),
+			int(math.Floor(r.Y*scale)),
+			int(math.Ceil((r.X+r.Width)*scale)),
+			int(math.Ceil((r.Y+r.Height)*scale)),
+		)
+		if !rect.Empty() {
+			rects = append(rects, rect)
+		}
+	}
+	return rects
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/shape"
+	"github.com/jezek/xgb/xproto"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// x11HitShapes sets input shapes over one connection, opened by the first
+// call, and remembers which X window each overlay is so that
+// _NET_CLIENT_LIST is only searched again when an overlay moves
+var x11HitShapes = &x11InputShapes{windows: make(map[*application.WebviewWindow]x11ShapedWindow)}
+
+type x11InputShapes struct {
+	mu      sync.Mutex
+	display *x11Display
+	windows map[*application.WebviewWindow]x11ShapedWindow
+}
+
+type x11ShapedWindow struct {
+	win    xproto.Window
+	bounds image.Rectangle // The monitor the window was found on
+}
+
+// setHitRegions makes only rects, in device pixels from the window's
+// corner, take the mouse by setting the window's X input shape. An empty
+// slice lets the mouse through the whole window. nil resets the shape and
+// leaves the whole window to SetIgnoreMouseEvents.
+func setHitRegions(w *application.WebviewWindow, placed Monitor, rects []image.Rectangle) error {
+	return x11HitShapes.set(w, placed, rects)
+}
+
+func (s *x11InputShapes) set(w *application.WebviewWindow, placed Monitor, rects []image.Rectangle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.display == nil {
+		d, err := openX11Display("_NET_CLIENT_LIST")
+		if err != nil {
+			return err
+		}
+		if err := shape.Init(d.conn); err != nil {
+			d.Close()
+			return fmt.Errorf("error initializing the X shape extension: %w", err)
+		}
+		s.display = d
+	}
+
+	win, err := s.window(w, placed)
+	if err != nil {
+		return err
+	}
+
+	var cookie interface{ Check() error }
+	if rects == nil {
+		// No mask is the default shape, the whole window
+		cookie = shape.MaskChecked(s.display.conn, shape.SoSet, shape.SkInput, win, 0, 0, xproto.PixmapNone)
+	} else {
+		shapes := make([]xproto.Rectangle, len(rects))
+		for i, r := range rects {
+			shapes[i] = xproto.Rectangle{
+				X:      int16(r.Min.X),
+				Y:      int16(r.Min.Y),
+				Width:  uint16(r.Dx()),
+				Height: uint16(r.Dy()),
+			}
+		}
+		cookie = shape.RectanglesChecked(s.display.conn, shape.SoSet, shape.SkInput, xproto.ClipOrderingUnsorted, win, 0, 0, shapes)
+	}
+
+	if err := cookie.Check(); err != nil {
+		// The window may have been destroyed, or the connection lost
+		delete(s.windows, w)
+		if _, ok := err.(xgb.Error); !ok {
+			s.display.Close()
+			s.display = nil
+			clear(s.windows)
+		}
+		return fmt.Errorf("error setting input shape: %w", err)
+	}
+	return nil
+}
+
+// window returns the X window of an overlay, looking it up again if the
+// overlay has moved since it was found. s.mu must be held.
+func (s *x11InputShapes) window(w *application.WebviewWindow, placed Monitor) (xproto.Window, error) {
+	bounds := image.Rect(placed.X, placed.Y, placed.X+placed.Width, placed.Y+placed.Height)
+	if cached, ok := s.windows[w]; ok && cached.bounds == bounds {
+		return cached.win, nil
+	}
+
+	win, err := s.display.ownWindowAt(placed)
+	if err != nil {
+		return 0, err
+	}
+
+	// A monitor only has one overlay, so anything else found there was
+	// closed or has moved
+	for other, cached := range s.windows {
+		if cached.bounds == bounds {
+			delete(s.windows, other)
+		}
+	}
+	s.windows[w] = x11ShapedWindow{win: win, bounds: bounds}
+
+	return win, nil
+}
+
+// ownWindowAt finds the client window of this process that covers a
+// monitor. GTK doesn't give out X window IDs without cgo, and an overlay is
+// the only window of ours filling its monitor.
+func (d *x11Display) ownWindowAt(m Monitor) (xproto.Window, error) {
+	pid := uint32(os.Getpid())
+
+	for _, id := range d.atomList(d.root, d.atoms["_NET_CLIENT_LIST"], xproto.AtomWindow) {
+		win := xproto.Window(id)
+
+		value := d.property(win, d.atoms["_NET_WM_PID"], xproto.AtomCardinal)
+		if len(value) < 4 || xgb.Get32(value) != pid {
+			continue
+		}
+
+		geom, err := xproto.GetGeometry(d.conn, xproto.Drawable(win)).Reply()
+		if err != nil {
+			continue
+		}
+		pos, err := xproto.TranslateCoordinates(d.conn, win, d.root, 0, 0).Reply()
+		if err != nil {
+			continue
+		}
+
+		if int(pos.DstX) == m.X && int(pos.DstY) == m.Y && int(geom.Width) == m.Width && int(geom.Height) == m.Height {
+			return win, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no overlay window found on %s", m.Name)
+}
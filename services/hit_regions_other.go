@@ -0,0 +1,19 @@
+//go:build !windows && !linux
+
+package services
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// setHitRegions reports that hit regions aren't supported on this platform.
+// Giving the whole window the mouse back always works.
+func setHitRegions(w *application.WebviewWindow, placed Monitor, rects []image.Rectangle) error {
+	if len(rects) == 0 {
+		return nil
+	}
+	return fmt.Errorf("hit regions are not supported on this platform")
+}
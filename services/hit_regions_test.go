@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+func TestHitRects(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []HitRegion
+		scale   float64
+		want    []image.Rectangle
+	}{
+		{
+			name:    "scale 1",
+			regions: []HitRegion{{X: 10, Y: 20, Width: 30, Height: 40}},
+			scale:   1,
+			want:    []image.Rectangle{image.Rect(10, 20, 40, 60)},
+		},
+		{
+			name:    "rounded outwards",
+			regions: []HitRegion{{X: 10.3, Y: 0, Width: 20, Height: 10}, {X: 1, Y: 1, Width: 1, Height: 1}},
+			scale:   1.25,
+			want:    []image.Rectangle{image.Rect(12, 0, 38, 13), image.Rect(1, 1, 3, 3)},
+		},
+		{
+			name:    "smaller than a pixel",
+			regions: []HitRegion{{X: 0.2, Y: 0.2, Width: 0.1, Height: 0.1}},
+			scale:   1,
+			want:    []image.Rectangle{image.Rect(0, 0, 1, 1)},
+		},
+		{
+			name:    "no scale",
+			regions: []HitRegion{{X: 1.5, Y: 2.5, Width: 1, Height: 1}},
+			scale:   0,
+			want:    []image.Rectangle{image.Rect(1, 2, 3, 4)},
+		},
+		{
+			name:    "negative scale",
+			regions: []HitRegion{{X: 1.5, Y: 2.5, Width: 1, Height: 1}},
+			scale:   -2,
+			want:    []image.Rectangle{image.Rect(1, 2, 3, 4)},
+		},
+		{
+			name: "empty dropped",
+			regions: []HitRegion{
+				{X: 5, Y: 5, Width: 0, Height: 10},
+				{X: 5, Y: 5, Width: 10, Height: 0},
+				{X: 10, Y: 5, Width: -5, Height: 10},
+				{X: 0, Y: 0, Width: 2, Height: 2},
+			},
+			scale: 2,
+			want:  []image.Rectangle{image.Rect(0, 0, 4, 4)},
+		},
+		{
+			name:    "all empty",
+			regions: []HitRegion{{Width: 0, Height: 0}},
+			scale:   1,
+			want:    []image.Rectangle{},
+		},
+		{
+			name:  "none",
+			scale: 1,
+			want:  []image.Rectangle{},
+		},
+	}
+
+	for _, tt := range tests {
+		got := hitRects(tt.regions, tt.scale)
+
+		// nil would give the window the whole mouse
+		if got == nil {
+			t.Errorf("%s: hitRects returned nil", tt.name)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: hitRects = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
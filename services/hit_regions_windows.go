@@ -0,0 +1,255 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	getCursorPos        = user32.NewProc("GetCursorPos")
+	setWindowLongPtr    = user32.NewProc("SetWindowLongPtrW")
+	setWindowsHookEx    = user32.NewProc("SetWindowsHookExW")
+	unhookWindowsHookEx = user32.NewProc("UnhookWindowsHookEx")
+	callNextHookEx      = user32.NewProc("CallNextHookEx")
+	peekMessage         = user32.NewProc("PeekMessageW")
+)
+
+const (
+	WS_EX_TRANSPARENT = 0x00000020
+	WS_EX_LAYERED     = 0x00080000
+	WH_MOUSE_LL       = 14
+	WM_MOUSEMOVE      = 0x0200
+	PM_NOREMOVE       = 0x0000
+)
+
+// msllHookStruct is the MSLLHOOKSTRUCT passed to a low-level mouse hook
+type msllHookStruct struct {
+	X, Y        int32
+	MouseData   uint32
+	Flags       uint32
+	Time        uint32
+	DwExtraInfo uintptr
+}
+
+// mouseHookCallback is created once, as Windows callbacks are never freed.
+// It only hands the cursor on, since a low-level hook that is slow to
+// return holds up the mouse of the whole desktop.
+var mouseHookCallback = syscall.NewCallback(func(code int, wParam uintptr, info *msllHookStruct) uintptr {
+	if code >= 0 && wParam == WM_MOUSEMOVE {
+		hitRegionTracker.moved(image.Pt(int(info.X), int(info.Y)))
+	}
+	ret, _, _ := callNextHookEx.Call(0, uintptr(code), wParam, uintptr(unsafe.Pointer(info)))
+	return ret
+})
+
+// hitTracker makes parts of click-through windows take the mouse.
+// WS_EX_TRANSPARENT windows are skipped by hit testing, so they never see
+// WM_NCHITTEST, and a window region would clip what the overlay draws as
+// well as where it takes the mouse. Instead a low-level mouse hook follows
+// the cursor, and WS_EX_TRANSPARENT is taken off a window while the cursor
+// is over one of its regions.
+type hitTracker struct {
+	mu       sync.Mutex
+	windows  map[uintptr][]image.Rectangle // Regions in screen pixels by window
+	inside   map[uintptr]bool              // Windows the cursor is over a region of
+	moves    chan image.Point              // The latest cursor position from the hook
+	stop     chan struct{}
+	threadID uint32        // The thread running the hook
+	done     chan struct{} // Closed once the hook is removed
+}
+
+var hitRegionTracker = &hitTracker{
+	windows: make(map[uintptr][]image.Rectangle),
+	inside:  make(map[uintptr]bool),
+	moves:   make(chan image.Point, 1),
+}
+
+// setHitRegions makes only rects, in device pixels from the window's
+// corner, take the mouse. A nil rects leaves the whole window to
+// SetIgnoreMouseEvents.
+func setHitRegions(w *application.WebviewWindow, placed Monitor, rects []image.Rectangle) error {
+	hwnd, err := w.NativeWindowHandle()
+	if err != nil {
+		return fmt.Errorf("error getting window handle: %w", err)
+	}
+
+	screen := make([]image.Rectangle, len(rects))
+	for i, r := range rects {
+		screen[i] = r.Add(image.Pt(placed.X, placed.Y))
+	}
+
+	hitRegionTracker.set(hwnd, screen, rects == nil)
+	return nil
+}
+
+func (t *hitTracker) set(hwnd uintptr, rects []image.Rectangle, release bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(rects) == 0 {
+		// The window is click-through everywhere again, unless it is
+		// being given the whole mouse
+		if t.inside[hwnd] && !release {
+			setTransparent(hwnd, true)
+		}
+		delete(t.windows, hwnd)
+		delete(t.inside, hwnd)
+	} else {
+		t.windows[hwnd] = rects
+	}
+
+	if len(t.windows) > 0 && t.stop == nil {
+		if err := t.startLocked(); err != nil {
+			fmt.Println("Error following the mouse for hit regions:", err)
+		}
+	} else if len(t.windows) == 0 && t.stop != nil {
+		t.stopLocked()
+	}
+
+	// The cursor may already be over a region that just appeared
+	var pt struct{ X, Y int32 }
+	if ret, _, _ := getCursorPos.Call(uintptr(unsafe.Pointer(&pt))); ret != 0 {
+		t.updateLocked(image.Pt(int(pt.X), int(pt.Y)))
+	}
+}
+
+// startLocked installs the mouse hook. t.mu must be held.
+func (t *hitTracker) startLocked() error {
+	t.done = make(chan struct{})
+	started := make(chan error)
+
+	go t.hook(started)
+
+	if err := <-started; err != nil {
+		return err
+	}
+
+	// Drop a position left over from the last time the hook ran
+	select {
+	case <-t.moves:
+	default:
+	}
+
+	t.stop = make(chan struct{})
+	go t.run(t.stop)
+	return nil
+}
+
+// stopLocked removes the mouse hook. t.mu must be held.
+func (t *hitTracker) stopLocked() {
+	close(t.stop)
+	t.stop = nil
+
+	postThreadMessage.Call(uintptr(t.threadID), WM_QUIT, 0, 0)
+	<-t.done
+}
+
+// hook installs the low-level mouse hook and pumps messages on a locked OS
+// thread until WM_QUIT is posted to it, as the hook is called from that
+// thread's message loop
+func (t *hitTracker) hook(started chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(t.done)
+
+	t.threadID = windows.GetCurrentThreadId()
+
+	var msg struct {
+		hwnd    uintptr
+		message uint32
+		wParam  uintptr
+		lParam  uintptr
+		time    uint32
+		pt      struct{ x, y int32 }
+	}
+
+	// Make sure the thread has a message queue before WM_QUIT can be posted
+	peekMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0, PM_NOREMOVE)
+
+	var module windows.Handle
+	windows.GetModuleHandleEx(0, nil, &module)
+
+	hook, _, err := setWindowsHookEx.Call(WH_MOUSE_LL, mouseHookCallback, uintptr(module), 0)
+	if hook == 0 {
+		started <- fmt.Errorf("SetWindowsHookEx failed: %v", err)
+		return
+	}
+	defer unhookWindowsHookEx.Call(hook)
+
+	started <- nil
+
+	for {
+		ret, _, _ := getMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			return
+		}
+		dispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
+
+// moved passes the cursor on from the hook without blocking, replacing a
+// position that hasn't been handled yet
+func (t *hitTracker) moved(pt image.Point) {
+	select {
+	case <-t.moves:
+	default:
+	}
+	select {
+	case t.moves <- pt:
+	default:
+	}
+}
+
+// run updates the windows as the cursor moves. Changing a window's style
+// waits on the thread that owns it, so it is kept out of the hook.
+func (t *hitTracker) run(stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case pt := <-t.moves:
+			t.mu.Lock()
+			t.updateLocked(pt)
+			t.mu.Unlock()
+		}
+	}
+}
+
+// updateLocked takes the mouse for the windows the cursor is over a region
+// of and lets it through the rest. t.mu must be held.
+func (t *hitTracker) updateLocked(cursor image.Point) {
+	for hwnd, rects := range t.windows {
+		inside := false
+		for _, r := range rects {
+			if cursor.In(r) {
+				inside = true
+				break
+			}
+		}
+
+		if inside != t.inside[hwnd] {
+			t.inside[hwnd] = inside
+			setTransparent(hwnd, !inside)
+		}
+	}
+}
+
+// setTransparent lets the mouse through a layered window, or takes it back
+func setTransparent(hwnd uintptr, transparent bool) {
+	index := int32(GWL_EXSTYLE)
+	exStyle, _, _ := getWindowLongPtr.Call(hwnd, uintptr(index))
+
+	if transparent {
+		exStyle |= WS_EX_TRANSPARENT | WS_EX_LAYERED
+	} else {
+		exStyle &^= WS_EX_TRANSPARENT
+	}
+	setWindowLongPtr.Call(hwnd, uintptr(index), exStyle)
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32              = windows.NewLazySystemDLL("user32.dll")
+	enumWindowsProc     = user32.NewProc("EnumWindows")
+	getWindowText       = user32.NewProc("GetWindowTextW")
+	getWindowTextLength = user32.NewProc("GetWindowTextLengthW")
+	isWindow            = user32.NewProc("IsWindow")
+)
@@ -3,15 +3,36 @@ package services
 import (
 	"fmt"
 	"sync"
-
-	"github.com/wailsapp/wails/v3/pkg/application"
-	"golang.design/x/hotkey"
+	"sync/atomic"
+	"time"
 )
 
 type HotkeyService struct {
-	hotkeys map[string]*hotkey.Hotkey
-	events  map[string]string
-	mu      sync.Mutex
+	backend         hotkeyBackend
+	emit            func(name string, data interface{})
+	listeners       atomic.Pointer[[]hotkeyListener] // Told about every hotkey that fires, replaced as a whole by onHotkey
+	bindings        map[string]*hotkeyBinding
+	leaders         map[string]*hotkeyLeader
+	suspended       bool
+	sequenceTimeout atomic.Int64 // How long to wait for each follow-up key
+	mu              sync.Mutex
+}
+
+// hotkeyBinding is a named hotkey. While it is active its first combination
+// is registered with the OS. A single combination has its own listener;
+// sequences share the listener of their leader.
+type hotkeyBinding struct {
+	steps   []HotkeyChord // One combination, or a leader followed by more keys
+	event   string
+	command *HotkeyCommand // Sent to Smash Soda when the hotkey fires
+	trigger hotkeyTrigger
+	enabled bool
+	active  bool
+
+	hk     hotkeyHandle  // Single combination: the registered hotkey
+	stop   chan struct{} // Single combination: closed to stop the listener
+	done   chan struct{} // Single combination: closed by the listener when it exits
+	leader *hotkeyLeader // Sequence: the shared leader
 }
 
 // ---- Argument structs (Wails-safe) ----
@@ -20,67 +41,244 @@ type RegisterHotkeyArgs struct {
 	Name      string
 	Modifiers []int
 	Key       int
-	Event     string
+	Hotkey    string         // e.g. "Ctrl+Alt+C" or "Ctrl+Alt+O C", used instead of Modifiers/Key when set
+	Event     string         // Local event to emit, optional when Command is set
+	Command   *HotkeyCommand // Message to send to Smash Soda, if any
+	Trigger   string         // press (default), release, hold or double-tap
+	HoldMs    int            // hold: how long to hold before firing
+	TapMs     int            // double-tap: longest gap between the two presses
 }
 
 type ChangeHotkeyArgs struct {
 	Name      string
 	Modifiers []int
 	Key       int
+	Hotkey    string // e.g. "Ctrl+Alt+C" or "Ctrl+Alt+O C", used instead of Modifiers/Key when set
 }
 
 // ---- Constructor ----
 
 func NewHotkeyService() *HotkeyService {
-	return &HotkeyService{
-		hotkeys: make(map[string]*hotkey.Hotkey),
-		events:  make(map[string]string),
+	return newHotkeyService(systemHotkeyBackend{}, emitAppEvent)
+}
+
+// newHotkeyService creates a HotkeyService with the given OS backend and
+// event sink, so that tests can swap both out
+func newHotkeyService(backend hotkeyBackend, emit func(name string, data interface{})) *HotkeyService {
+	h := &HotkeyService{
+		backend:  backend,
+		emit:     emit,
+		bindings: make(map[string]*hotkeyBinding),
+		leaders:  make(map[string]*hotkeyLeader),
 	}
+	h.sequenceTimeout.Store(int64(defaultSequenceTimeout))
+	return h
 }
 
 // ---- Public API (exported, binding-safe) ----
 
+// RegisterHotkey registers a global hotkey that emits args.Event and sends
+// args.Command to Smash Soda. Registering a name again replaces its previous
+// hotkey.
 func (h *HotkeyService) RegisterHotkey(args RegisterHotkeyArgs) error {
+	steps, err := resolveHotkeySequence(args.Hotkey, args.Modifiers, args.Key)
+	if err != nil {
+		return &HotkeyError{Name: args.Name, Hotkey: args.Hotkey, Err: ErrHotkeyInvalid, Cause: err}
+	}
+
+	if err := checkHotkeySequence(args.Name, steps); err != nil {
+		return err
+	}
+
+	trigger, err := newSequenceTrigger(steps, args.Trigger, args.HoldMs, args.TapMs)
+	if err != nil {
+		return &HotkeyError{Name: args.Name, Hotkey: sequenceID(steps), Err: ErrHotkeyInvalid, Cause: err}
+	}
+
+	command, err := newHotkeyCommand(args.Command)
+	if err == nil && args.Event == "" && command == nil {
+		err = fmt.Errorf("hotkey has no event or command")
+	}
+	if err != nil {
+		return &HotkeyError{Name: args.Name, Hotkey: sequenceID(steps), Err: ErrHotkeyInvalid, Cause: err}
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	modifiers := mapModifiers(args.Modifiers)
-	key := mapKey(args.Key)
-
-	hk := hotkey.New(modifiers, key)
-	if err := hk.Register(); err != nil {
+	if err := h.checkDuplicateLocked(args.Name, steps); err != nil {
 		return err
 	}
 
-	h.hotkeys[args.Name] = hk
-	h.events[args.Name] = args.Event
+	if old, exists := h.bindings[args.Name]; exists {
+		h.deactivateLocked(args.Name, old)
+		delete(h.bindings, args.Name)
+	}
+
+	b := &hotkeyBinding{
+		steps:   steps,
+		event:   args.Event,
+		command: command,
+		trigger: trigger,
+		enabled: true,
+	}
+
+	if !h.suspended {
+		if err := h.activateLocked(args.Name, b); err != nil {
+			return err
+		}
+	}
 
-	h.startListener(hk, args.Name, args.Event)
+	h.bindings[args.Name] = b
+
+	fmt.Printf("Registered hotkey %s: %s\n", args.Name, sequenceID(steps))
 
 	return nil
 }
 
+// ChangeHotkey rebinds an already registered hotkey. The previous
+// combination is kept if the new one can't be registered.
 func (h *HotkeyService) ChangeHotkey(args ChangeHotkeyArgs) error {
+	steps, err := resolveHotkeySequence(args.Hotkey, args.Modifiers, args.Key)
+	if err != nil {
+		return &HotkeyError{Name: args.Name, Hotkey: args.Hotkey, Err: ErrHotkeyInvalid, Cause: err}
+	}
+
+	if err := checkHotkeySequence(args.Name, steps); err != nil {
+		return err
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	hk, exists := h.hotkeys[args.Name]
+	b, exists := h.bindings[args.Name]
 	if !exists {
 		return fmt.Errorf("hotkey with name %s does not exist", args.Name)
 	}
 
-	hk.Unregister()
-
-	modifiers := mapModifiers(args.Modifiers)
-	key := mapKey(args.Key)
+	if len(steps) > 1 && b.trigger.mode != HotkeyTriggerPress {
+		return &HotkeyError{Name: args.Name, Hotkey: sequenceID(steps), Err: ErrHotkeyInvalid, Cause: errSequenceTrigger}
+	}
 
-	newHK := hotkey.New(modifiers, key)
-	if err := newHK.Register(); err != nil {
+	if err := h.checkDuplicateLocked(args.Name, steps); err != nil {
 		return err
 	}
 
-	h.hotkeys[args.Name] = newHK
-	h.startListener(newHK, args.Name, h.events[args.Name])
+	oldSteps := b.steps
+	wasActive := b.active
+
+	h.deactivateLocked(args.Name, b)
+	b.steps = steps
+
+	if wasActive {
+		if err := h.activateLocked(args.Name, b); err != nil {
+			b.steps = oldSteps
+			if restoreErr := h.activateLocked(args.Name, b); restoreErr != nil {
+				fmt.Printf("Failed to restore hotkey %s: %v\n", args.Name, restoreErr)
+			}
+			return err
+		}
+	}
+
+	fmt.Printf("Changed hotkey %s: %s\n", args.Name, sequenceID(steps))
+
+	return nil
+}
+
+// UnregisterHotkey removes a single hotkey
+func (h *HotkeyService) UnregisterHotkey(name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, exists := h.bindings[name]
+	if !exists {
+		return fmt.Errorf("hotkey with name %s does not exist", name)
+	}
+
+	h.deactivateLocked(name, b)
+	delete(h.bindings, name)
+
+	return nil
+}
+
+// SetEnabled turns a hotkey on or off. A disabled hotkey keeps its
+// combination but releases it to other applications.
+func (h *HotkeyService) SetEnabled(name string, enabled bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, exists := h.bindings[name]
+	if !exists {
+		return fmt.Errorf("hotkey with name %s does not exist", name)
+	}
+
+	if b.enabled == enabled {
+		return nil
+	}
+	b.enabled = enabled
+
+	if !enabled {
+		h.deactivateLocked(name, b)
+		return nil
+	}
+
+	if h.suspended {
+		return nil
+	}
+
+	return h.activateLocked(name, b)
+}
+
+// Suspend releases all hotkeys without forgetting them, e.g. while a text
+// field in the overlay has focus
+func (h *HotkeyService) Suspend() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.suspended {
+		return
+	}
+	h.suspended = true
+
+	for name, b := range h.bindings {
+		h.deactivateLocked(name, b)
+	}
+}
+
+// Resume registers the enabled hotkeys again after Suspend
+func (h *HotkeyService) Resume() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.suspended {
+		return nil
+	}
+	h.suspended = false
+
+	var firstErr error
+	for name, b := range h.bindings {
+		if !b.enabled {
+			continue
+		}
+		if err := h.activateLocked(name, b); err != nil {
+			fmt.Println("Failed to resume hotkey:", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// SetSequenceTimeout sets how long a sequence such as "Ctrl+Alt+O C" waits
+// for each follow-up key before giving up
+func (h *HotkeyService) SetSequenceTimeout(ms int) error {
+	if ms <= 0 {
+		return fmt.Errorf("sequence timeout must be positive")
+	}
+
+	h.sequenceTimeout.Store(int64(time.Duration(ms) * time.Millisecond))
 
 	return nil
 }
@@ -89,52 +287,176 @@ func (h *HotkeyService) UnregisterAll() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	for _, hk := range h.hotkeys {
-		hk.Unregister()
+	for name, b := range h.bindings {
+		h.deactivateLocked(name, b)
 	}
 
-	h.hotkeys = make(map[string]*hotkey.Hotkey)
-	h.events = make(map[string]string)
+	h.bindings = make(map[string]*hotkeyBinding)
+}
+
+// ParseHotkey parses a hotkey string such as "Ctrl+Alt+F1" into modifier and
+// key codes
+func (h *HotkeyService) ParseHotkey(hotkey string) (HotkeyChord, error) {
+	return ParseHotkey(hotkey)
+}
+
+// FormatHotkey returns a readable name for a modifier and key combination,
+// such as "Ctrl+Alt+F1"
+func (h *HotkeyService) FormatHotkey(modifiers []int, key int) string {
+	return FormatHotkey(modifiers, key)
 }
 
 // ---- Internal helpers (NOT exported) ----
 
-func (h *HotkeyService) startListener(hk *hotkey.Hotkey, name, event string) {
-	go func(hk *hotkey.Hotkey, name, event string) {
-		for range hk.Keydown() {
-			if WailsApp == nil {
-				continue
-			}
+// activateLocked registers a binding's first combination with the OS and
+// starts listening for it. h.mu must be held.
+func (h *HotkeyService) activateLocked(name string, b *hotkeyBinding) error {
+	if b.active {
+		return nil
+	}
 
-			WailsApp.Event.EmitEvent(&application.CustomEvent{
-				Name: event,
-				Data: map[string]interface{}{
-					"name": name,
-				},
-			})
+	if len(b.steps) > 1 {
+		if err := h.joinLeaderLocked(name, b); err != nil {
+			return err
 		}
-	}(hk, name, event)
+		b.active = true
+		return nil
+	}
+
+	hk, err := h.backend.Register(b.steps[0])
+	if err != nil {
+		return registerHotkeyError(name, b.steps[0], err)
+	}
+
+	b.hk = hk
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	b.active = true
+
+	h.startListener(hk, name, b)
+
+	return nil
+}
+
+// deactivateLocked stops listening for a binding, waits for its listener to
+// exit and releases the combination. h.mu must be held.
+func (h *HotkeyService) deactivateLocked(name string, b *hotkeyBinding) {
+	if !b.active {
+		return
+	}
+	b.active = false
+
+	if b.leader != nil {
+		h.leaveLeaderLocked(name, b)
+		return
+	}
+
+	close(b.stop)
+	<-b.done
+
+	b.hk.Unregister()
+	b.hk = nil
+	b.stop = nil
+	b.done = nil
 }
 
-func mapKey(keyCode int) hotkey.Key {
-	return hotkey.Key(keyCode)
+// hotkeyListener is told about every hotkey that fires
+type hotkeyListener func(name string, data HotkeyEventData)
+
+// onHotkey adds a listener for every hotkey that fires, such as the scene
+// rules. Listeners are called from the hotkey's listener goroutine, so they
+// must not call back into the service while it waits for them.
+func (h *HotkeyService) onHotkey(listener hotkeyListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	listeners := make([]hotkeyListener, 0, 1)
+	if current := h.listeners.Load(); current != nil {
+		listeners = append(listeners, *current...)
+	}
+	listeners = append(listeners, listener)
+	h.listeners.Store(&listeners)
 }
 
-func mapModifiers(modifierCodes []int) []hotkey.Modifier {
-	modifiers := make([]hotkey.Modifier, 0, len(modifierCodes))
+// fire emits a binding's event and sends its command
+func (h *HotkeyService) fire(name, hotkey string, b *hotkeyBinding, data HotkeyEventData) {
+	now := time.Now()
+
+	data.Name = name
+	data.Trigger = b.trigger.mode
+	data.Time = now.UnixMilli()
 
-	for _, code := range modifierCodes {
-		switch code {
-		case 16:
-			modifiers = append(modifiers, hotkey.ModShift)
-		case 17:
-			modifiers = append(modifiers, hotkey.ModCtrl)
-		case 18:
-			modifiers = append(modifiers, hotkey.ModAlt)
-		case 91, 92:
-			modifiers = append(modifiers, hotkey.ModWin)
+	if b.event != "" {
+		h.emit(b.event, data)
+	}
+
+	if listeners := h.listeners.Load(); listeners != nil {
+		for _, listener := range *listeners {
+			listener(name, data)
 		}
 	}
 
-	return modifiers
+	if b.command != nil && firesCommand(data.Phase) {
+		h.sendHotkeyCommand(b.command, name, hotkey, now)
+	}
+}
+
+// resolveHotkeySequence picks the hotkey string when one is given, otherwise
+// the raw modifier and key codes
+func resolveHotkeySequence(hotkey string, modifiers []int, key int) ([]HotkeyChord, error) {
+	if hotkey != "" {
+		return ParseHotkeySequence(hotkey)
+	}
+
+	if key == 0 {
+		return nil, fmt.Errorf("hotkey has no key")
+	}
+
+	return []HotkeyChord{{Modifiers: normalizeHotkeyModifiers(modifiers), Key: key}}, nil
+}
+
+// startListener forwards key events as hotkey events, according to the
+// binding's trigger, until the binding's stop channel is closed. The
+// listener must not take h.mu, as deactivateLocked waits for it while
+// holding it.
+func (h *HotkeyService) startListener(hk hotkeyHandle, name string, b *hotkeyBinding) {
+	keydown := hk.Keydown()
+	keyup := hk.Keyup()
+	stop := b.stop
+	done := b.done
+	hotkey := sequenceID(b.steps)
+
+	go func() {
+		defer close(done)
+
+		state := &hotkeyTriggerState{trigger: b.trigger}
+		emit := func(data HotkeyEventData, ok bool) {
+			if !ok {
+				return
+			}
+			h.fire(name, hotkey, b, data)
+		}
+
+		for {
+			select {
+			case <-stop:
+				emit(state.stop(time.Now()))
+				return
+			case _, ok := <-keydown:
+				if !ok {
+					state.stopHoldTimer()
+					return
+				}
+				emit(state.keydown(time.Now()))
+			case _, ok := <-keyup:
+				if !ok {
+					state.stopHoldTimer()
+					return
+				}
+				emit(state.keyup(time.Now()))
+			case <-state.holdC():
+				emit(state.held(time.Now()))
+			}
+		}
+	}()
 }
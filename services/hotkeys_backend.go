@@ -0,0 +1,19 @@
+package services
+
+// hotkeyBackend registers global hotkeys with the OS. It is an interface so
+// that HotkeyService can run against an in-memory fake.
+type hotkeyBackend interface {
+	Register(chord HotkeyChord) (hotkeyHandle, error)
+}
+
+// hotkeyHandle is a combination registered with a hotkeyBackend. Its event
+// channels are closed when it is unregistered.
+type hotkeyHandle interface {
+	Keydown() <-chan struct{}
+	Keyup() <-chan struct{}
+	Unregister() error
+}
+
+// systemHotkeyBackend registers hotkeys with the OS. Each platform provides
+// its Register method.
+type systemHotkeyBackend struct{}
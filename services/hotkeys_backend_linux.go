@@ -0,0 +1,336 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// x11Keysyms maps virtual-key codes to X keysyms. Letters, digits, function
+// keys and the numpad are worked out in mapKey.
+var x11Keysyms = map[int]xproto.Keysym{
+	0x08: 0xFF08, // Backspace
+	0x09: 0xFF09, // Tab
+	0x0D: 0xFF0D, // Enter
+	0x13: 0xFF13, // Pause
+	0x14: 0xFFE5, // CapsLock
+	0x1B: 0xFF1B, // Esc
+	0x20: 0x0020, // Space
+	0x21: 0xFF55, // PageUp
+	0x22: 0xFF56, // PageDown
+	0x23: 0xFF57, // End
+	0x24: 0xFF50, // Home
+	0x25: 0xFF51, // Left
+	0x26: 0xFF52, // Up
+	0x27: 0xFF53, // Right
+	0x28: 0xFF54, // Down
+	0x2C: 0xFF61, // PrintScreen
+	0x2D: 0xFF63, // Insert
+	0x2E: 0xFFFF, // Delete
+	0x5D: 0xFF67, // Menu
+
+	0x6A: 0xFFAA, // NumpadMultiply
+	0x6B: 0xFFAB, // NumpadAdd
+	0x6C: 0xFFAC, // NumpadSeparator
+	0x6D: 0xFFAD, // NumpadSubtract
+	0x6E: 0xFFAE, // NumpadDecimal
+	0x6F: 0xFFAF, // NumpadDivide
+	0x90: 0xFF7F, // NumLock
+	0x91: 0xFF14, // ScrollLock
+
+	0xBA: ';',
+	0xBB: '=',
+	0xBC: ',',
+	0xBD: '-',
+	0xBE: '.',
+	0xBF: '/',
+	0xC0: '`',
+	0xDB: '[',
+	0xDC: '\\',
+	0xDD: ']',
+	0xDE: '\'',
+}
+
+// Caps Lock and Num Lock (usually Mod2) don't change which hotkey was
+// pressed, so every combination of them is grabbed as well
+var x11LockModifiers = []uint16{0, xproto.ModMaskLock, xproto.ModMask2, xproto.ModMaskLock | xproto.ModMask2}
+
+// Modifiers that are part of a hotkey
+const x11HotkeyModifiers = xproto.ModMaskShift | xproto.ModMaskControl | xproto.ModMask1 | xproto.ModMask4
+
+// x11Hotkeys grabs hotkeys on the root window with XGrabKey. The connection
+// is opened by the first registration and closed with the last.
+var x11Hotkeys = &x11HotkeyGrabs{}
+
+type x11HotkeyGrabs struct {
+	mu      sync.Mutex
+	display *x11Display
+	keymap  map[xproto.Keysym]xproto.Keycode
+	handles map[x11Grab]*x11HotkeyHandle
+	pressed map[xproto.Keycode]*x11HotkeyHandle // Keys held down, whatever the modifiers are by the time they are released
+}
+
+type x11Grab struct {
+	key       xproto.Keycode
+	modifiers uint16
+}
+
+type x11HotkeyHandle struct {
+	grab    x11Grab
+	keydown chan struct{}
+	keyup   chan struct{}
+}
+
+func (systemHotkeyBackend) Register(chord HotkeyChord) (hotkeyHandle, error) {
+	keysym, ok := mapKey(chord.Key)
+	if !ok {
+		return nil, fmt.Errorf("%s can't be used as a hotkey on Linux", FormatHotkey(nil, chord.Key))
+	}
+
+	h, err := x11Hotkeys.register(keysym, mapModifiers(chord.Modifiers))
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (g *x11HotkeyGrabs) register(keysym xproto.Keysym, modifiers uint16) (*x11HotkeyHandle, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.openLocked(); err != nil {
+		return nil, err
+	}
+	defer g.closeIfUnusedLocked()
+
+	key, ok := g.keymap[keysym]
+	if !ok {
+		return nil, fmt.Errorf("no key on the keyboard produces keysym %#x", keysym)
+	}
+
+	grab := x11Grab{key: key, modifiers: modifiers}
+	if _, ok := g.handles[grab]; ok {
+		return nil, ErrHotkeyInUse
+	}
+
+	for i, lock := range x11LockModifiers {
+		err := xproto.GrabKeyChecked(g.display.conn, true, g.display.root, modifiers|lock, key,
+			xproto.GrabModeAsync, xproto.GrabModeAsync).Check()
+		if err == nil {
+			continue
+		}
+
+		g.ungrabLocked(grab, x11LockModifiers[:i])
+
+		var access xproto.AccessError
+		if errors.As(err, &access) {
+			return nil, fmt.Errorf("%w: %v", ErrHotkeyInUse, err)
+		}
+		return nil, fmt.Errorf("error grabbing key: %w", err)
+	}
+
+	h := &x11HotkeyHandle{
+		grab:    grab,
+		keydown: make(chan struct{}, 16),
+		keyup:   make(chan struct{}, 16),
+	}
+	g.handles[grab] = h
+
+	return h, nil
+}
+
+func (h *x11HotkeyHandle) Keydown() <-chan struct{} { return h.keydown }
+func (h *x11HotkeyHandle) Keyup() <-chan struct{}   { return h.keyup }
+
+func (h *x11HotkeyHandle) Unregister() error {
+	g := x11Hotkeys
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.handles[h.grab] != h {
+		return nil
+	}
+	delete(g.handles, h.grab)
+	for key, held := range g.pressed {
+		if held == h {
+			delete(g.pressed, key)
+		}
+	}
+
+	err := g.ungrabLocked(h.grab, x11LockModifiers)
+	close(h.keydown)
+	close(h.keyup)
+
+	g.closeIfUnusedLocked()
+	return err
+}
+
+// openLocked connects to the X server and reads the keyboard mapping, if
+// that hasn't been done yet. g.mu must be held.
+func (g *x11HotkeyGrabs) openLocked() error {
+	if g.display != nil {
+		return nil
+	}
+
+	display, err := openX11Display()
+	if err != nil {
+		return err
+	}
+
+	setup := xproto.Setup(display.conn)
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+	mapping, err := xproto.GetKeyboardMapping(display.conn, setup.MinKeycode, count).Reply()
+	if err != nil {
+		display.Close()
+		return fmt.Errorf("error reading keyboard mapping: %w", err)
+	}
+
+	// Look keys up by their unshifted and shifted keysyms
+	keymap := make(map[xproto.Keysym]xproto.Keycode)
+	perKey := int(mapping.KeysymsPerKeycode)
+	for i := 0; i < int(count); i++ {
+		for j := 0; j < perKey && j < 2; j++ {
+			keysym := mapping.Keysyms[i*perKey+j]
+			if _, ok := keymap[keysym]; keysym != 0 && !ok {
+				keymap[keysym] = setup.MinKeycode + xproto.Keycode(i)
+			}
+		}
+	}
+
+	g.display = display
+	g.keymap = keymap
+	g.handles = make(map[x11Grab]*x11HotkeyHandle)
+	g.pressed = make(map[xproto.Keycode]*x11HotkeyHandle)
+	go g.run(display)
+
+	return nil
+}
+
+// closeIfUnusedLocked closes the connection once nothing is grabbed.
+// g.mu must be held.
+func (g *x11HotkeyGrabs) closeIfUnusedLocked() {
+	if g.display == nil || len(g.handles) > 0 {
+		return
+	}
+
+	g.display.Close()
+	g.display = nil
+}
+
+// ungrabLocked releases a grab for the given lock modifiers. g.mu must be
+// held.
+func (g *x11HotkeyGrabs) ungrabLocked(grab x11Grab, locks []uint16) error {
+	var err error
+	for _, lock := range locks {
+		if e := xproto.UngrabKeyChecked(g.display.conn, grab.key, g.display.root, grab.modifiers|lock).Check(); e != nil && err == nil {
+			err = fmt.Errorf("error releasing key: %w", e)
+		}
+	}
+	return err
+}
+
+// run passes key events on to the handles until the connection is closed
+func (g *x11HotkeyGrabs) run(display *x11Display) {
+	var next xgb.Event
+
+	for {
+		ev := next
+		next = nil
+		if ev == nil {
+			e, err := display.conn.WaitForEvent()
+			if e == nil && err == nil {
+				return // Connection closed
+			}
+			if err != nil {
+				continue
+			}
+			ev = e
+		}
+
+		switch e := ev.(type) {
+		case xproto.KeyPressEvent:
+			g.keyEvent(e.Detail, e.State, true)
+
+		case xproto.KeyReleaseEvent:
+			// Auto-repeat sends a release and a press with the same time
+			// while a key is held. Drop both so that holds aren't cut short.
+			if queued, _ := display.conn.PollForEvent(); queued != nil {
+				if press, ok := queued.(xproto.KeyPressEvent); ok && press.Detail == e.Detail && press.Time == e.Time {
+					continue
+				}
+				next = queued
+			}
+			g.keyEvent(e.Detail, e.State, false)
+		}
+	}
+}
+
+// keyEvent reports a grabbed key going down or up. Events are dropped
+// rather than block the connection if a listener falls far behind.
+func (g *x11HotkeyGrabs) keyEvent(key xproto.Keycode, state uint16, down bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var h *x11HotkeyHandle
+	var ch chan struct{}
+	if down {
+		h = g.handles[x11Grab{key: key, modifiers: state & x11HotkeyModifiers}]
+		if h == nil {
+			return
+		}
+		g.pressed[key] = h
+		ch = h.keydown
+	} else {
+		h = g.pressed[key]
+		if h == nil {
+			return
+		}
+		delete(g.pressed, key)
+		ch = h.keyup
+	}
+
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func mapKey(keyCode int) (xproto.Keysym, bool) {
+	switch {
+	case keyCode >= '0' && keyCode <= '9':
+		return xproto.Keysym(keyCode), true
+	case keyCode >= 'A' && keyCode <= 'Z':
+		return xproto.Keysym(keyCode + 'a' - 'A'), true // Keysyms for letters are lower case
+	case keyCode >= 0x60 && keyCode <= 0x69: // Numpad0-9
+		return xproto.Keysym(0xFFB0 + keyCode - 0x60), true
+	case keyCode >= 0x70 && keyCode <= 0x87: // F1-F24
+		return xproto.Keysym(0xFFBE + keyCode - 0x70), true
+	}
+
+	key, ok := x11Keysyms[keyCode]
+	return key, ok
+}
+
+// mapModifiers turns virtual-key modifiers into an X modifier mask
+func mapModifiers(modifierCodes []int) uint16 {
+	var modifiers uint16
+
+	for _, code := range normalizeHotkeyModifiers(modifierCodes) {
+		switch code {
+		case vkShift:
+			modifiers |= xproto.ModMaskShift
+		case vkCtrl:
+			modifiers |= xproto.ModMaskControl
+		case vkAlt:
+			modifiers |= xproto.ModMask1
+		case vkLWin:
+			modifiers |= xproto.ModMask4
+		}
+	}
+
+	return modifiers
+}
@@ -0,0 +1,12 @@
+//go:build !windows && !linux
+
+package services
+
+import (
+	"fmt"
+)
+
+// Register reports that global hotkeys aren't available on this platform
+func (systemHotkeyBackend) Register(chord HotkeyChord) (hotkeyHandle, error) {
+	return nil, fmt.Errorf("global hotkeys are not supported on this platform")
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"sync"
+
+	"golang.design/x/hotkey"
+)
+
+// systemHotkeyHandle adapts a hotkey from golang.design/x/hotkey, whose
+// events it forwards until it is unregistered
+type systemHotkeyHandle struct {
+	hk      *hotkey.Hotkey
+	keydown chan struct{}
+	keyup   chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (systemHotkeyBackend) Register(chord HotkeyChord) (hotkeyHandle, error) {
+	hk, err := newSystemHotkey(chord)
+	if err != nil {
+		return nil, err
+	}
+	if err := hk.Register(); err != nil {
+		return nil, err
+	}
+
+	s := &systemHotkeyHandle{
+		hk:      hk,
+		keydown: make(chan struct{}),
+		keyup:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	// The library replaces its channels on Unregister, so take them now
+	go s.forward(hk.Keydown(), s.keydown)
+	go s.forward(hk.Keyup(), s.keyup)
+
+	return s, nil
+}
+
+// forward passes events on until the library closes its channel or the
+// handle is unregistered, then closes out
+func (s *systemHotkeyHandle) forward(in <-chan hotkey.Event, out chan<- struct{}) {
+	defer close(out)
+
+	for {
+		select {
+		case _, ok := <-in:
+			if !ok {
+				return
+			}
+			select {
+			case out <- struct{}{}:
+			case <-s.done:
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *systemHotkeyHandle) Keydown() <-chan struct{} { return s.keydown }
+func (s *systemHotkeyHandle) Keyup() <-chan struct{}   { return s.keyup }
+
+func (s *systemHotkeyHandle) Unregister() error {
+	err := s.hk.Unregister()
+	s.once.Do(func() { close(s.done) })
+	return err
+}
+
+// newSystemHotkey creates a hotkey for the chord. Windows takes
+// virtual-key codes as they are.
+func newSystemHotkey(chord HotkeyChord) (*hotkey.Hotkey, error) {
+	return hotkey.New(mapModifiers(chord.Modifiers), mapKey(chord.Key)), nil
+}
+
+func mapKey(keyCode int) hotkey.Key {
+	return hotkey.Key(keyCode)
+}
+
+func mapModifiers(modifierCodes []int) []hotkey.Modifier {
+	normalized := normalizeHotkeyModifiers(modifierCodes)
+	modifiers := make([]hotkey.Modifier, 0, len(normalized))
+
+	for _, code := range normalized {
+		switch code {
+		case vkShift:
+			modifiers = append(modifiers, hotkey.ModShift)
+		case vkCtrl:
+			modifiers = append(modifiers, hotkey.ModCtrl)
+		case vkAlt:
+			modifiers = append(modifiers, hotkey.ModAlt)
+		case vkLWin:
+			modifiers = append(modifiers, hotkey.ModWin)
+		}
+	}
+
+	return modifiers
+}
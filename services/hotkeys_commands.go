@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventSocketSend asks the frontend to send a message to Smash Soda over its
+// socket connection, as {event, data}
+const EventSocketSend = "socket:send"
+
+// HotkeyCommand is a message sent to Smash Soda when a hotkey fires, such as
+// {"event": "chat:send", "data": "Be right back!"}. String values in Data
+// may use the placeholders {{name}}, {{hotkey}} and {{time}}.
+type HotkeyCommand struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// newHotkeyCommand validates a command and copies its data, so later
+// changes by the caller don't affect the binding
+func newHotkeyCommand(c *HotkeyCommand) (*HotkeyCommand, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	if strings.TrimSpace(c.Event) == "" {
+		return nil, fmt.Errorf("hotkey command has no event")
+	}
+
+	raw, err := json.Marshal(c.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding hotkey command data: %w", err)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("error decoding hotkey command data: %w", err)
+	}
+
+	return &HotkeyCommand{Event: c.Event, Data: data}, nil
+}
+
+// sendHotkeyCommand fills in the command's placeholders and sends it to
+// Smash Soda
+func (h *HotkeyService) sendHotkeyCommand(c *HotkeyCommand, name, hotkey string, now time.Time) {
+	vars := strings.NewReplacer(
+		"{{name}}", name,
+		"{{hotkey}}", hotkey,
+		"{{time}}", strconv.FormatInt(now.UnixMilli(), 10),
+	)
+
+	h.emit(EventSocketSend, HotkeyCommand{
+		Event: c.Event,
+		Data:  expandCommandData(c.Data, vars),
+	})
+}
+
+// firesCommand reports whether a phase fires the hotkey's command. Holds
+// send it once when the hold starts.
+func firesCommand(phase string) bool {
+	return phase != HotkeyPhaseHoldEnd
+}
+
+// expandCommandData returns a copy of data with placeholders replaced in
+// every string value
+func expandCommandData(data interface{}, vars *strings.Replacer) interface{} {
+	switch v := data.(type) {
+	case string:
+		return vars.Replace(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = expandCommandData(item, vars)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = expandCommandData(item, vars)
+		}
+		return out
+	default:
+		return v
+	}
+}
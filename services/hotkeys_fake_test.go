@@ -0,0 +1,164 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+)
+
+// fakeHotkeyBackend is an in-memory hotkeyBackend for tests. It never talks
+// to the OS; key presses are simulated with Press and Release.
+type fakeHotkeyBackend struct {
+	mu       sync.Mutex
+	handles  map[string]*fakeHotkeyHandle
+	failures map[string]error
+	blocks   map[string]*fakeHotkeyBlock
+}
+
+// fakeHotkeyBlock holds up the next registration of a combination
+type fakeHotkeyBlock struct {
+	entered chan struct{} // Closed once the registration is waiting
+	release chan struct{}
+}
+
+type fakeHotkeyHandle struct {
+	backend *fakeHotkeyBackend
+	id      string
+	keydown chan struct{}
+	keyup   chan struct{}
+}
+
+func newFakeHotkeyBackend() *fakeHotkeyBackend {
+	return &fakeHotkeyBackend{
+		handles:  make(map[string]*fakeHotkeyHandle),
+		failures: make(map[string]error),
+		blocks:   make(map[string]*fakeHotkeyBlock),
+	}
+}
+
+func (f *fakeHotkeyBackend) Register(chord HotkeyChord) (hotkeyHandle, error) {
+	id := chordID(chord)
+
+	f.mu.Lock()
+	block := f.blocks[id]
+	delete(f.blocks, id)
+	f.mu.Unlock()
+
+	if block != nil {
+		close(block.entered)
+		<-block.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err, ok := f.failures[id]; ok {
+		return nil, err
+	}
+	if _, taken := f.handles[id]; taken {
+		return nil, errnoHotkeyAlreadyRegistered
+	}
+
+	handle := &fakeHotkeyHandle{
+		backend: f,
+		id:      id,
+		keydown: make(chan struct{}, 16),
+		keyup:   make(chan struct{}, 16),
+	}
+	f.handles[id] = handle
+
+	return handle, nil
+}
+
+// Fail makes registering a combination return err, as if another
+// application held it
+func (f *fakeHotkeyBackend) Fail(hotkeyStr string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures[mustChordID(hotkeyStr)] = err
+}
+
+// Block makes the next registration of a combination wait, like a slow
+// OS, until release is called. entered is closed once it is waiting.
+func (f *fakeHotkeyBackend) Block(hotkeyStr string) (entered <-chan struct{}, release func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	block := &fakeHotkeyBlock{entered: make(chan struct{}), release: make(chan struct{})}
+	f.blocks[mustChordID(hotkeyStr)] = block
+
+	return block.entered, func() { close(block.release) }
+}
+
+// Press simulates a keydown. It returns false if the combination isn't
+// registered.
+func (f *fakeHotkeyBackend) Press(hotkeyStr string) bool {
+	return f.send(hotkeyStr, true)
+}
+
+// Release simulates a keyup. It returns false if the combination isn't
+// registered.
+func (f *fakeHotkeyBackend) Release(hotkeyStr string) bool {
+	return f.send(hotkeyStr, false)
+}
+
+// IsRegistered reports whether a combination is currently registered
+func (f *fakeHotkeyBackend) IsRegistered(hotkeyStr string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.handles[mustChordID(hotkeyStr)]
+	return ok
+}
+
+// Registered returns the number of registered combinations
+func (f *fakeHotkeyBackend) Registered() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.handles)
+}
+
+func (f *fakeHotkeyBackend) send(hotkeyStr string, down bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	handle, ok := f.handles[mustChordID(hotkeyStr)]
+	if !ok {
+		return false
+	}
+
+	if down {
+		handle.keydown <- struct{}{}
+	} else {
+		handle.keyup <- struct{}{}
+	}
+
+	return true
+}
+
+func (h *fakeHotkeyHandle) Keydown() <-chan struct{} { return h.keydown }
+func (h *fakeHotkeyHandle) Keyup() <-chan struct{}   { return h.keyup }
+
+func (h *fakeHotkeyHandle) Unregister() error {
+	h.backend.mu.Lock()
+	defer h.backend.mu.Unlock()
+
+	if h.backend.handles[h.id] != h {
+		return fmt.Errorf("hotkey is not registered")
+	}
+
+	delete(h.backend.handles, h.id)
+	close(h.keydown)
+	close(h.keyup)
+
+	return nil
+}
+
+func mustChordID(hotkeyStr string) string {
+	chord, err := ParseHotkey(hotkeyStr)
+	if err != nil {
+		panic(err)
+	}
+	return chordID(chord)
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HotkeyChord is a key combination written with Windows virtual-key codes,
+// the same codes used by RegisterHotkeyArgs.
+type HotkeyChord struct {
+	Modifiers []int
+	Key       int
+}
+
+// Virtual-key codes of the modifier keys
+const (
+	vkShift = 0x10
+	vkCtrl  = 0x11
+	vkAlt   = 0x12
+	vkLWin  = 0x5B
+	vkRWin  = 0x5C
+)
+
+// Modifiers in the order they are written, e.g. "Ctrl+Alt+Shift+Win+F1"
+var hotkeyModifierOrder = []int{vkCtrl, vkAlt, vkShift, vkLWin}
+
+var hotkeyModifierNames = map[int]string{
+	vkCtrl:  "Ctrl",
+	vkAlt:   "Alt",
+	vkShift: "Shift",
+	vkLWin:  "Win",
+}
+
+var hotkeyModifierAliases = map[string]int{
+	"ctrl":    vkCtrl,
+	"control": vkCtrl,
+	"alt":     vkAlt,
+	"option":  vkAlt,
+	"shift":   vkShift,
+	"win":     vkLWin,
+	"windows": vkLWin,
+	"super":   vkLWin,
+	"meta":    vkLWin,
+	"cmd":     vkLWin,
+}
+
+// hotkeyKeyNames maps virtual-key codes to the name used when formatting.
+// Letters, digits and function keys are added in init.
+var hotkeyKeyNames = map[int]string{
+	0x08: "Backspace",
+	0x09: "Tab",
+	0x0D: "Enter",
+	0x13: "Pause",
+	0x14: "CapsLock",
+	0x1B: "Esc",
+	0x20: "Space",
+	0x21: "PageUp",
+	0x22: "PageDown",
+	0x23: "End",
+	0x24: "Home",
+	0x25: "Left",
+	0x26: "Up",
+	0x27: "Right",
+	0x28: "Down",
+	0x2C: "PrintScreen",
+	0x2D: "Insert",
+	0x2E: "Delete",
+	0x5D: "Menu",
+
+	0x6A: "NumpadMultiply",
+	0x6B: "NumpadAdd",
+	0x6C: "NumpadSeparator",
+	0x6D: "NumpadSubtract",
+	0x6E: "NumpadDecimal",
+	0x6F: "NumpadDivide",
+	0x90: "NumLock",
+	0x91: "ScrollLock",
+
+	0xA6: "BrowserBack",
+	0xA7: "BrowserForward",
+	0xA8: "BrowserRefresh",
+	0xA9: "BrowserStop",
+	0xAA: "BrowserSearch",
+	0xAB: "BrowserFavorites",
+	0xAC: "BrowserHome",
+	0xAD: "VolumeMute",
+	0xAE: "VolumeDown",
+	0xAF: "VolumeUp",
+	0xB0: "MediaNext",
+	0xB1: "MediaPrevious",
+	0xB2: "MediaStop",
+	0xB3: "MediaPlayPause",
+	0xB4: "LaunchMail",
+	0xB5: "LaunchMedia",
+	0xB6: "LaunchApp1",
+	0xB7: "LaunchApp2",
+
+	0xBA: ";",
+	0xBB: "=",
+	0xBC: ",",
+	0xBD: "-",
+	0xBE: ".",
+	0xBF: "/",
+	0xC0: "`",
+	0xDB: "[",
+	0xDC: "\\",
+	0xDD: "]",
+	0xDE: "'",
+}
+
+// hotkeyKeyAliases maps lower case key names accepted when parsing to
+// virtual-key codes. Every name in hotkeyKeyNames is accepted as well.
+var hotkeyKeyAliases = map[string]int{
+	"return":       0x0D,
+	"escape":       0x1B,
+	"spacebar":     0x20,
+	"pgup":         0x21,
+	"pgdn":         0x22,
+	"pagedn":       0x22,
+	"arrowleft":    0x25,
+	"arrowup":      0x26,
+	"arrowright":   0x27,
+	"arrowdown":    0x28,
+	"prtsc":        0x2C,
+	"printscr":     0x2C,
+	"ins":          0x2D,
+	"del":          0x2E,
+	"apps":         0x5D,
+	"contextmenu":  0x5D,
+	"break":        0x13,
+	"numpad*":      0x6A,
+	"numpad-":      0x6D,
+	"numpad.":      0x6E,
+	"numpad/":      0x6F,
+	"mute":         0xAD,
+	"playpause":    0xB3,
+	"mediaplay":    0xB3,
+	"mediaprev":    0xB1,
+	"nexttrack":    0xB0,
+	"prevtrack":    0xB1,
+	"semicolon":    0xBA,
+	"equal":        0xBB,
+	"equals":       0xBB,
+	"plus":         0xBB,
+	"comma":        0xBC,
+	"minus":        0xBD,
+	"period":       0xBE,
+	"slash":        0xBF,
+	"backquote":    0xC0,
+	"grave":        0xC0,
+	"tilde":        0xC0,
+	"bracketleft":  0xDB,
+	"backslash":    0xDC,
+	"bracketright": 0xDD,
+	"quote":        0xDE,
+	"apostrophe":   0xDE,
+}
+
+func init() {
+	for c := 'A'; c <= 'Z'; c++ {
+		hotkeyKeyNames[int(c)] = string(c)
+	}
+	for c := '0'; c <= '9'; c++ {
+		hotkeyKeyNames[int(c)] = string(c)
+		hotkeyKeyNames[0x60+int(c-'0')] = "Numpad" + string(c)
+	}
+	for i := 1; i <= 24; i++ {
+		hotkeyKeyNames[0x6F+i] = fmt.Sprintf("F%d", i)
+	}
+
+	for code, name := range hotkeyKeyNames {
+		hotkeyKeyAliases[strings.ToLower(name)] = code
+	}
+}
+
+// ParseHotkey parses a hotkey written like "Ctrl+Alt+F1" into modifier and
+// key codes. Names are case-insensitive, and keys without a name can be
+// written as a hex virtual-key code such as "0x7B".
+func ParseHotkey(s string) (HotkeyChord, error) {
+	chord := HotkeyChord{Modifiers: make([]int, 0)}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return chord, fmt.Errorf("hotkey is empty")
+	}
+
+	parts := strings.Split(s, "+")
+
+	// "Ctrl++" uses the plus key
+	if strings.HasSuffix(s, "++") {
+		parts = append(parts[:len(parts)-2], "plus")
+	}
+
+	seen := make(map[int]bool)
+	for i, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			return chord, fmt.Errorf("invalid hotkey %q", s)
+		}
+
+		if i < len(parts)-1 {
+			mod, ok := hotkeyModifierAliases[name]
+			if !ok {
+				return chord, fmt.Errorf("unknown modifier %q in hotkey %q", part, s)
+			}
+			if !seen[mod] {
+				seen[mod] = true
+				chord.Modifiers = append(chord.Modifiers, mod)
+			}
+			continue
+		}
+
+		key, err := parseHotkeyKey(name)
+		if err != nil {
+			return chord, fmt.Errorf("%w in hotkey %q", err, s)
+		}
+		chord.Key = key
+	}
+
+	sortHotkeyModifiers(chord.Modifiers)
+
+	return chord, nil
+}
+
+// FormatHotkey writes a modifier and key combination in the form accepted
+// by ParseHotkey, e.g. "Ctrl+Alt+F1"
+func FormatHotkey(modifiers []int, key int) string {
+	parts := make([]string, 0, len(modifiers)+1)
+
+	normalized := normalizeHotkeyModifiers(modifiers)
+	for _, mod := range normalized {
+		parts = append(parts, hotkeyModifierNames[mod])
+	}
+
+	if name, ok := hotkeyKeyNames[key]; ok {
+		parts = append(parts, name)
+	} else {
+		parts = append(parts, fmt.Sprintf("0x%02X", key))
+	}
+
+	return strings.Join(parts, "+")
+}
+
+// parseHotkeyKey parses the key part of a hotkey string
+func parseHotkeyKey(name string) (int, error) {
+	if _, isModifier := hotkeyModifierAliases[name]; isModifier {
+		return 0, fmt.Errorf("missing key after modifier %q", name)
+	}
+
+	if code, ok := hotkeyKeyAliases[name]; ok {
+		return code, nil
+	}
+
+	if strings.HasPrefix(name, "0x") {
+		code, err := strconv.ParseUint(name[2:], 16, 8)
+		if err == nil && code > 0 {
+			return int(code), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown key %q", name)
+}
+
+// normalizeHotkeyModifiers turns left/right variants of modifier keys into
+// their generic codes, removes duplicates and unknown codes, and sorts them
+func normalizeHotkeyModifiers(modifiers []int) []int {
+	normalized := make([]int, 0, len(modifiers))
+	seen := make(map[int]bool)
+
+	for _, code := range modifiers {
+		switch code {
+		case 0xA0, 0xA1:
+			code = vkShift
+		case 0xA2, 0xA3:
+			code = vkCtrl
+		case 0xA4, 0xA5:
+			code = vkAlt
+		case vkRWin:
+			code = vkLWin
+		}
+
+		if _, ok := hotkeyModifierNames[code]; !ok || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+
+	sortHotkeyModifiers(normalized)
+
+	return normalized
+}
+
+func sortHotkeyModifiers(modifiers []int) {
+	rank := func(code int) int {
+		for i, mod := range hotkeyModifierOrder {
+			if mod == code {
+				return i
+			}
+		}
+		return len(hotkeyModifierOrder)
+	}
+
+	sort.SliceStable(modifiers, func(i, j int) bool {
+		return rank(modifiers[i]) < rank(modifiers[j])
+	})
+}
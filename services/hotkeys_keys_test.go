@@ -0,0 +1,99 @@
+package services
+
+import "testing"
+
+func TestParseHotkey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // Empty if ParseHotkey must fail
+	}{
+		// Round trips
+		{"Ctrl+Alt+F1", "Ctrl+Alt+F1"},
+		{"Ctrl+Alt+Shift+Win+Delete", "Ctrl+Alt+Shift+Win+Delete"},
+		{"Shift+A", "Shift+A"},
+		{"Win+Space", "Win+Space"},
+		{"Esc", "Esc"},
+		{"F24", "F24"},
+		{"Numpad5", "Numpad5"},
+		{"Ctrl+=", "Ctrl+="},
+		{"Ctrl+-", "Ctrl+-"},
+		{"Alt+0x07", "Alt+0x07"},
+
+		// Case, whitespace, aliases and modifier order
+		{"ctrl + alt + c", "Ctrl+Alt+C"},
+		{"  SHIFT+ctrl+f1 ", "Ctrl+Shift+F1"},
+		{"control+escape", "Ctrl+Esc"},
+		{"cmd+option+pgdn", "Alt+Win+PageDown"},
+		{"Ctrl+Ctrl+A", "Ctrl+A"},
+		{"Ctrl++", "Ctrl+="},
+		{"Alt+0x7b", "Alt+F12"},
+
+		// Rejected
+		{"", ""},
+		{"   ", ""},
+		{"Ctrl", ""},
+		{"Ctrl+Alt", ""},
+		{"Ctrl+", ""},
+		{"+A", ""},
+		{"Ctrl+Foo", ""},
+		{"A+B", ""},
+		{"Ctrl+A+A", ""},
+		{"0x00", ""},
+		{"0x100", ""},
+	}
+
+	for _, tt := range tests {
+		chord, err := ParseHotkey(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseHotkey(%q) = %s, want an error", tt.in, FormatHotkey(chord.Modifiers, chord.Key))
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseHotkey(%q): %v", tt.in, err)
+			continue
+		}
+		if got := FormatHotkey(chord.Modifiers, chord.Key); got != tt.want {
+			t.Errorf("FormatHotkey(ParseHotkey(%q)) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseHotkeySequence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // Empty if ParseHotkeySequence must fail
+	}{
+		{"Ctrl+K Ctrl+C", "Ctrl+K Ctrl+C"},
+		{"Ctrl+K C", "Ctrl+K C"},
+		{"F13", "F13"},
+		{"ctrl + k   c", "Ctrl+K C"},
+		{" Ctrl +Alt+ O  shift+x ", "Ctrl+Alt+O Shift+X"},
+		{"Ctrl++ A", "Ctrl+= A"},
+
+		{"", ""},
+		{"   ", ""},
+		{"Ctrl+K Foo", ""},
+		{"Ctrl+K Ctrl", ""},
+	}
+
+	for _, tt := range tests {
+		steps, err := ParseHotkeySequence(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseHotkeySequence(%q) = %s, want an error", tt.in, FormatHotkeySequence(steps))
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseHotkeySequence(%q): %v", tt.in, err)
+			continue
+		}
+		if got := FormatHotkeySequence(steps); got != tt.want {
+			t.Errorf("FormatHotkeySequence(ParseHotkeySequence(%q)) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
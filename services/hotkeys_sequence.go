@@ -0,0 +1,494 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Events emitted while a key sequence such as "Ctrl+Alt+O C" is typed, so
+// the overlay can show which keys are pending
+const (
+	EventHotkeySequencePending = "hotkey:sequence:pending"
+	EventHotkeySequenceEnd     = "hotkey:sequence:end"
+)
+
+// Results reported by EventHotkeySequenceEnd
+const (
+	HotkeySequenceMatched   = "matched"
+	HotkeySequenceTimeout   = "timeout"
+	HotkeySequenceCancelled = "cancelled"
+)
+
+const (
+	defaultSequenceTimeout = 1500 * time.Millisecond
+	vkEscape               = 0x1B
+)
+
+var errSequenceTrigger = errors.New("key sequences only support the press trigger")
+
+// HotkeySequenceStep is a key that continues a pending sequence
+type HotkeySequenceStep struct {
+	Hotkey string `json:"hotkey"`
+	Name   string `json:"name,omitempty"` // The hotkey it completes, if any
+}
+
+// HotkeySequenceState is the payload of the sequence events
+type HotkeySequenceState struct {
+	Keys      []string             `json:"keys"`                // Keys pressed so far
+	Next      []HotkeySequenceStep `json:"next,omitempty"`      // pending: keys that can follow
+	TimeoutMs int64                `json:"timeoutMs,omitempty"` // pending: time left to press one
+	Result    string               `json:"result,omitempty"`    // end: matched, timeout or cancelled
+	Name      string               `json:"name,omitempty"`      // end: the hotkey that matched
+}
+
+// hotkeyLeader is the first combination shared by one or more sequences.
+// Only the leader is registered globally; follow-up keys are grabbed while
+// a sequence is pending and released straight after.
+type hotkeyLeader struct {
+	chord HotkeyChord
+	hk    hotkeyHandle
+	stop  chan struct{}
+	done  chan struct{}
+
+	mu      sync.Mutex // Guards members, which the listener reads
+	members map[string]*hotkeyBinding
+}
+
+// ParseHotkeySequence parses one or more combinations separated by spaces,
+// such as "Ctrl+Alt+O C". A single combination is a sequence of one.
+func ParseHotkeySequence(s string) ([]HotkeyChord, error) {
+	fields := splitHotkeySequence(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("hotkey is empty")
+	}
+
+	steps := make([]HotkeyChord, 0, len(fields))
+	for _, field := range fields {
+		chord, err := ParseHotkey(field)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, chord)
+	}
+
+	return steps, nil
+}
+
+// FormatHotkeySequence writes a sequence in the form accepted by
+// ParseHotkeySequence
+func FormatHotkeySequence(steps []HotkeyChord) string {
+	return sequenceID(steps)
+}
+
+// splitHotkeySequence splits a sequence on spaces, keeping spaces around
+// "+" inside a combination, e.g. "Ctrl + Alt + O C"
+func splitHotkeySequence(s string) []string {
+	fields := make([]string, 0)
+	current := ""
+	joinNext := false
+
+	for _, word := range strings.Fields(s) {
+		switch {
+		case current == "":
+			current = word
+		case joinNext || word[0] == '+':
+			current += word
+		default:
+			fields = append(fields, current)
+			current = word
+		}
+		joinNext = word[len(word)-1] == '+' && !(len(word) >= 2 && word[len(word)-2] == '+')
+	}
+
+	if current != "" {
+		fields = append(fields, current)
+	}
+
+	return fields
+}
+
+// newSequenceTrigger validates the trigger of a binding. Sequences only
+// fire on press.
+func newSequenceTrigger(steps []HotkeyChord, mode string, holdMs, tapMs int) (hotkeyTrigger, error) {
+	trigger, err := newHotkeyTrigger(mode, holdMs, tapMs)
+	if err != nil {
+		return trigger, err
+	}
+
+	if len(steps) > 1 && trigger.mode != HotkeyTriggerPress {
+		return trigger, errSequenceTrigger
+	}
+
+	return trigger, nil
+}
+
+// sequenceID returns a canonical name for a sequence
+func sequenceID(steps []HotkeyChord) string {
+	id := ""
+	for i, chord := range steps {
+		if i > 0 {
+			id += " "
+		}
+		id += chordID(chord)
+	}
+	return id
+}
+
+// sequencesConflict reports whether one sequence is a prefix of the other.
+// Such sequences can't both be bound, as the shorter one always wins.
+func sequencesConflict(a, b []HotkeyChord) bool {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for i := 0; i < n; i++ {
+		if chordID(a[i]) != chordID(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// followUpConflict reports whether a follow-up key of either sequence is
+// the first combination of the other. Follow-up keys are only grabbed while
+// their sequence is pending, which fails while another hotkey holds them.
+func followUpConflict(a, b []HotkeyChord) bool {
+	return hasFollowUp(a, b[0]) || hasFollowUp(b, a[0])
+}
+
+// hasFollowUp reports whether a combination continues a sequence
+func hasFollowUp(steps []HotkeyChord, chord HotkeyChord) bool {
+	id := chordID(chord)
+	for _, step := range steps[1:] {
+		if chordID(step) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// joinLeaderLocked adds a sequence to its leader, registering the leader
+// when it is the first. h.mu must be held.
+func (h *HotkeyService) joinLeaderLocked(name string, b *hotkeyBinding) error {
+	id := chordID(b.steps[0])
+
+	l, exists := h.leaders[id]
+	if !exists {
+		hk, err := h.backend.Register(b.steps[0])
+		if err != nil {
+			return registerHotkeyError(name, b.steps[0], err)
+		}
+
+		l = &hotkeyLeader{
+			chord:   b.steps[0],
+			hk:      hk,
+			stop:    make(chan struct{}),
+			done:    make(chan struct{}),
+			members: make(map[string]*hotkeyBinding),
+		}
+		h.leaders[id] = l
+
+		h.startLeaderListener(l)
+	}
+
+	l.mu.Lock()
+	l.members[name] = b
+	l.mu.Unlock()
+
+	b.leader = l
+
+	return nil
+}
+
+// leaveLeaderLocked removes a sequence from its leader, releasing the
+// leader when no sequences are left. h.mu must be held.
+func (h *HotkeyService) leaveLeaderLocked(name string, b *hotkeyBinding) {
+	l := b.leader
+	b.leader = nil
+
+	l.mu.Lock()
+	delete(l.members, name)
+	remaining := len(l.members)
+	l.mu.Unlock()
+
+	if remaining > 0 {
+		return
+	}
+
+	close(l.stop)
+	<-l.done
+
+	l.hk.Unregister()
+	delete(h.leaders, chordID(l.chord))
+}
+
+// startLeaderListener runs a sequence each time the leader is pressed,
+// until the leader is unregistered. Like startListener it must not take h.mu.
+func (h *HotkeyService) startLeaderListener(l *hotkeyLeader) {
+	keydown := l.hk.Keydown()
+	keyup := l.hk.Keyup()
+
+	go func() {
+		defer close(l.done)
+
+		for {
+			select {
+			case <-l.stop:
+				return
+			case _, ok := <-keydown:
+				if !ok {
+					return
+				}
+				h.runSequence(l)
+			case _, ok := <-keyup:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// runSequence follows a sequence after its leader was pressed, emitting the
+// bound event once every key has been typed
+func (h *HotkeyService) runSequence(l *hotkeyLeader) {
+	pressed := []HotkeyChord{l.chord}
+	keys := []string{chordID(l.chord)}
+
+	end := func(result, name string) {
+		h.emit(EventHotkeySequenceEnd, HotkeySequenceState{Keys: keys, Result: result, Name: name})
+	}
+
+	for {
+		next, matched := l.follow(pressed)
+
+		if matched != "" {
+			h.emitSequenceMatch(l, matched, pressed)
+			end(HotkeySequenceMatched, matched)
+			return
+		}
+
+		if len(next) == 0 {
+			end(HotkeySequenceCancelled, "")
+			return
+		}
+
+		timeout := time.Duration(h.sequenceTimeout.Load())
+
+		steps := make([]HotkeySequenceStep, 0, len(next))
+		for _, step := range next {
+			steps = append(steps, step.HotkeySequenceStep)
+		}
+		h.emit(EventHotkeySequencePending, HotkeySequenceState{
+			Keys:      keys,
+			Next:      steps,
+			TimeoutMs: timeout.Milliseconds(),
+		})
+
+		chord, result := h.awaitSequenceKey(l, next, timeout)
+		if result != "" {
+			end(result, "")
+			return
+		}
+
+		pressed = append(pressed, chord)
+		keys = append(keys, chordID(chord))
+	}
+}
+
+// sequenceFollowUp is a key that can continue a pending sequence
+type sequenceFollowUp struct {
+	HotkeySequenceStep
+	chord HotkeyChord
+}
+
+// follow returns the keys that can follow the ones pressed so far, and the
+// name of the sequence they complete, if any
+func (l *hotkeyLeader) follow(pressed []HotkeyChord) ([]sequenceFollowUp, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	byID := make(map[string]*sequenceFollowUp)
+	for name, b := range l.members {
+		if len(b.steps) < len(pressed) || !sequencesConflict(b.steps, pressed) {
+			continue
+		}
+
+		if len(b.steps) == len(pressed) {
+			return nil, name
+		}
+
+		chord := b.steps[len(pressed)]
+		id := chordID(chord)
+
+		step, exists := byID[id]
+		if !exists {
+			step = &sequenceFollowUp{HotkeySequenceStep: HotkeySequenceStep{Hotkey: id}, chord: chord}
+			byID[id] = step
+		}
+		if len(b.steps) == len(pressed)+1 {
+			step.Name = name
+		}
+	}
+
+	next := make([]sequenceFollowUp, 0, len(byID))
+	for _, step := range byID {
+		next = append(next, *step)
+	}
+	sort.Slice(next, func(i, j int) bool { return next[i].Hotkey < next[j].Hotkey })
+
+	return next, ""
+}
+
+// emitSequenceMatch fires the hotkey bound to a completed sequence, as long
+// as it wasn't removed while it was being typed
+func (h *HotkeyService) emitSequenceMatch(l *hotkeyLeader, name string, pressed []HotkeyChord) {
+	l.mu.Lock()
+	b, exists := l.members[name]
+	l.mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	h.fire(name, sequenceID(pressed), b, HotkeyEventData{
+		Phase:    HotkeyPhaseDown,
+		Sequence: sequenceID(pressed),
+	})
+}
+
+// awaitSequenceKey grabs the follow-up keys, plus Esc to cancel, and waits
+// for one of them. It returns the key pressed, or the result that ended the
+// sequence.
+func (h *HotkeyService) awaitSequenceKey(l *hotkeyLeader, next []sequenceFollowUp, timeout time.Duration) (HotkeyChord, string) {
+	chords := make([]HotkeyChord, 0, len(next)+1)
+	canCancel := true
+	for _, step := range next {
+		chords = append(chords, step.chord)
+		if chordID(step.chord) == chordID(HotkeyChord{Key: vkEscape}) {
+			canCancel = false
+		}
+	}
+	if canCancel {
+		chords = append(chords, HotkeyChord{Modifiers: make([]int, 0), Key: vkEscape})
+	}
+
+	grab := h.grabSequenceKeys(chords)
+	defer grab.release()
+
+	if grab.count == 0 || (canCancel && grab.count == 1 && grab.cancelHeld) {
+		fmt.Println("Failed to grab any keys to continue the sequence")
+		return HotkeyChord{}, HotkeySequenceCancelled
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	leaderDown := l.hk.Keydown()
+	leaderUp := l.hk.Keyup()
+
+	for {
+		select {
+		case chord := <-grab.pressed:
+			if canCancel && chord.Key == vkEscape && len(chord.Modifiers) == 0 {
+				return HotkeyChord{}, HotkeySequenceCancelled
+			}
+			return chord, ""
+		case <-timer.C:
+			return HotkeyChord{}, HotkeySequenceTimeout
+		case <-l.stop:
+			return HotkeyChord{}, HotkeySequenceCancelled
+		case _, ok := <-leaderDown:
+			// Pressing the leader again while waiting is ignored
+			if !ok {
+				return HotkeyChord{}, HotkeySequenceCancelled
+			}
+		case _, ok := <-leaderUp:
+			if !ok {
+				return HotkeyChord{}, HotkeySequenceCancelled
+			}
+		}
+	}
+}
+
+// sequenceGrab holds follow-up keys registered while a sequence is pending
+type sequenceGrab struct {
+	handles    []hotkeyHandle
+	pressed    chan HotkeyChord
+	quit       chan struct{}
+	wg         sync.WaitGroup
+	count      int
+	cancelHeld bool
+}
+
+// grabSequenceKeys registers each combination, skipping ones that another
+// application holds, and forwards their presses
+func (h *HotkeyService) grabSequenceKeys(chords []HotkeyChord) *sequenceGrab {
+	g := &sequenceGrab{
+		pressed: make(chan HotkeyChord),
+		quit:    make(chan struct{}),
+	}
+
+	for _, chord := range chords {
+		hk, err := h.backend.Register(chord)
+		if err != nil {
+			fmt.Printf("Failed to grab %s for hotkey sequence: %v\n", chordID(chord), err)
+			continue
+		}
+
+		g.handles = append(g.handles, hk)
+		g.count++
+		if chord.Key == vkEscape && len(chord.Modifiers) == 0 {
+			g.cancelHeld = true
+		}
+
+		g.wg.Add(1)
+		go g.forward(chord, hk)
+	}
+
+	return g
+}
+
+func (g *sequenceGrab) forward(chord HotkeyChord, hk hotkeyHandle) {
+	defer g.wg.Done()
+
+	keydown := hk.Keydown()
+	keyup := hk.Keyup()
+
+	for {
+		select {
+		case <-g.quit:
+			return
+		case _, ok := <-keydown:
+			if !ok {
+				return
+			}
+			select {
+			case g.pressed <- chord:
+			case <-g.quit:
+				return
+			}
+		case _, ok := <-keyup:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// release gives the grabbed keys back to other applications
+func (g *sequenceGrab) release() {
+	close(g.quit)
+
+	for _, hk := range g.handles {
+		hk.Unregister()
+	}
+
+	g.wg.Wait()
+}
@@ -0,0 +1,536 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func newTestHotkeyService() (*HotkeyService, *fakeHotkeyBackend, *eventRecorder) {
+	backend := newFakeHotkeyBackend()
+	events := &eventRecorder{}
+	return newHotkeyService(backend, events.emit), backend, events
+}
+
+// waitForGoroutines fails the test unless the number of goroutines drops
+// back to want, giving exiting listeners a moment to finish
+func waitForGoroutines(t *testing.T, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := runtime.NumGoroutine()
+		if got <= want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines = %d, want %d", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func assertRegistered(t *testing.T, backend *fakeHotkeyBackend, want int) {
+	t.Helper()
+
+	if got := backend.Registered(); got != want {
+		t.Fatalf("Registered() = %d, want %d", got, want)
+	}
+}
+
+func TestHotkeyServiceDoesNotLeak(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	h, backend, _ := newTestHotkeyService()
+
+	for _, args := range []RegisterHotkeyArgs{
+		{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"},
+		{Name: "hold", Hotkey: "Ctrl+Alt+H", Event: "hotkey:hold", Trigger: HotkeyTriggerHold},
+		{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"},
+		{Name: "poll", Hotkey: "Ctrl+Alt+O P", Event: "hotkey:poll"},
+	} {
+		if err := h.RegisterHotkey(args); err != nil {
+			t.Fatalf("RegisterHotkey(%s): %v", args.Name, err)
+		}
+	}
+	// The two sequences share their leader
+	assertRegistered(t, backend, 3)
+
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+Y"}); err != nil {
+		t.Fatalf("ChangeHotkey: %v", err)
+	}
+	assertRegistered(t, backend, 3)
+	if backend.IsRegistered("Ctrl+Alt+T") || !backend.IsRegistered("Ctrl+Alt+Y") {
+		t.Fatal("ChangeHotkey kept the old combination registered")
+	}
+
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+K C"}); err != nil {
+		t.Fatalf("ChangeHotkey: %v", err)
+	}
+	assertRegistered(t, backend, 4)
+
+	if err := h.UnregisterHotkey("poll"); err != nil {
+		t.Fatalf("UnregisterHotkey: %v", err)
+	}
+	assertRegistered(t, backend, 3)
+
+	h.Suspend()
+	assertRegistered(t, backend, 0)
+
+	if err := h.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	assertRegistered(t, backend, 3)
+
+	// Re-registering a name replaces its hotkey
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "hold", Hotkey: "Ctrl+Alt+J", Event: "hotkey:hold"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	assertRegistered(t, backend, 3)
+
+	h.UnregisterAll()
+	assertRegistered(t, backend, 0)
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestHotkeyServiceSuspendDoesNotLeak(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	h, backend, _ := newTestHotkeyService()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		h.Suspend()
+		assertRegistered(t, backend, 0)
+
+		if err := h.Resume(); err != nil {
+			t.Fatalf("Resume: %v", err)
+		}
+		assertRegistered(t, backend, 2)
+	}
+
+	// Changes made while suspended only register on Resume
+	h.Suspend()
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+Y"}); err != nil {
+		t.Fatalf("ChangeHotkey: %v", err)
+	}
+	if err := h.UnregisterHotkey("chat"); err != nil {
+		t.Fatalf("UnregisterHotkey: %v", err)
+	}
+	assertRegistered(t, backend, 0)
+
+	if err := h.Resume(); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	assertRegistered(t, backend, 1)
+	if !backend.IsRegistered("Ctrl+Alt+Y") {
+		t.Fatal("Resume didn't register the changed combination")
+	}
+
+	h.UnregisterAll()
+	assertRegistered(t, backend, 0)
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestRegisterHotkeyEmitsEvent(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	if !backend.IsRegistered("Ctrl+Alt+T") {
+		t.Fatal("Ctrl+Alt+T isn't registered")
+	}
+
+	backend.Press("Ctrl+Alt+T")
+	backend.Release("Ctrl+Alt+T")
+
+	data := events.waitFor(t, "hotkey:toggle", 1)[0].(HotkeyEventData)
+	if data.Name != "toggle" || data.Trigger != HotkeyTriggerPress || data.Phase != HotkeyPhaseDown {
+		t.Fatalf("event = %+v, want toggle/press/down", data)
+	}
+
+	// Press only fires on the way down
+	time.Sleep(20 * time.Millisecond)
+	if n := len(events.named("hotkey:toggle")); n != 1 {
+		t.Fatalf("emitted %d events, want 1", n)
+	}
+}
+
+func TestRegisterHotkeyReleaseTrigger(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	err := h.RegisterHotkey(RegisterHotkeyArgs{
+		Name:    "push",
+		Hotkey:  "Ctrl+Alt+P",
+		Event:   "hotkey:push",
+		Trigger: HotkeyTriggerRelease,
+	})
+	if err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	// The listener picks between ready channels at random, so let it see
+	// the press before the release
+	backend.Press("Ctrl+Alt+P")
+	time.Sleep(20 * time.Millisecond)
+	backend.Release("Ctrl+Alt+P")
+
+	data := events.waitFor(t, "hotkey:push", 1)[0].(HotkeyEventData)
+	if data.Phase != HotkeyPhaseUp {
+		t.Fatalf("phase = %s, want %s", data.Phase, HotkeyPhaseUp)
+	}
+}
+
+func TestRegisterHotkeySendsCommand(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	err := h.RegisterHotkey(RegisterHotkeyArgs{
+		Name:   "brb",
+		Hotkey: "Ctrl+Alt+B",
+		Command: &HotkeyCommand{
+			Event: "chat:send",
+			Data:  map[string]interface{}{"message": "{{name}} via {{hotkey}}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	backend.Press("Ctrl+Alt+B")
+
+	command := events.waitFor(t, EventSocketSend, 1)[0].(HotkeyCommand)
+	if command.Event != "chat:send" {
+		t.Fatalf("command event = %s, want chat:send", command.Event)
+	}
+	data := command.Data.(map[string]interface{})
+	if data["message"] != "brb via Ctrl+Alt+B" {
+		t.Fatalf("message = %v, want placeholders filled in", data["message"])
+	}
+}
+
+func TestRegisterHotkeyErrors(t *testing.T) {
+	h, backend, _ := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "empty", Hotkey: "Ctrl+Alt+E"}); !errors.Is(err, ErrHotkeyInvalid) {
+		t.Fatalf("hotkey without event or command: err = %v, want ErrHotkeyInvalid", err)
+	}
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "other", Hotkey: "Ctrl+Alt+T", Event: "hotkey:other"})
+	if !errors.Is(err, ErrHotkeyDuplicate) {
+		t.Fatalf("duplicate hotkey: err = %v, want ErrHotkeyDuplicate", err)
+	}
+
+	backend.Fail("Ctrl+Alt+U", errnoHotkeyAlreadyRegistered)
+	err = h.RegisterHotkey(RegisterHotkeyArgs{Name: "taken", Hotkey: "Ctrl+Alt+U", Event: "hotkey:taken"})
+	if !errors.Is(err, ErrHotkeyInUse) {
+		t.Fatalf("hotkey held by another application: err = %v, want ErrHotkeyInUse", err)
+	}
+
+	assertRegistered(t, backend, 1)
+}
+
+func TestValidateHotkeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      []RegisterHotkeyArgs
+		problems []string // As "name:kind:conflict"
+	}{
+		{
+			name: "valid",
+			set: []RegisterHotkeyArgs{
+				{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"},
+				{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"},
+				{Name: "poll", Hotkey: "Ctrl+Alt+O P", Event: "hotkey:poll"},
+			},
+		},
+		{
+			name: "invalid syntax",
+			set: []RegisterHotkeyArgs{
+				{Name: "unknown", Hotkey: "Ctrl+Foo", Event: "hotkey:unknown"},
+				{Name: "modifier", Hotkey: "Ctrl+Alt", Event: "hotkey:modifier"},
+				{Name: "trigger", Hotkey: "Ctrl+Alt+T", Event: "hotkey:trigger", Trigger: "sometimes"},
+				{Name: "silent", Hotkey: "Ctrl+Alt+S"},
+			},
+			problems: []string{"unknown:invalid:", "modifier:invalid:", "trigger:invalid:", "silent:invalid:"},
+		},
+		{
+			name: "duplicate chord",
+			set: []RegisterHotkeyArgs{
+				{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"},
+				{Name: "other", Hotkey: "Alt+Ctrl+T", Event: "hotkey:other"},
+			},
+			problems: []string{"other:duplicate:toggle"},
+		},
+		{
+			name: "sequence prefix",
+			set: []RegisterHotkeyArgs{
+				{Name: "menu", Hotkey: "Ctrl+Alt+O", Event: "hotkey:menu"},
+				{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"},
+				{Name: "poll", Hotkey: "Ctrl+Alt+P C", Event: "hotkey:poll"},
+				{Name: "vote", Hotkey: "Ctrl+Alt+P C V", Event: "hotkey:vote"},
+			},
+			problems: []string{"chat:duplicate:menu", "vote:duplicate:poll"},
+		},
+		{
+			name: "reserved",
+			set: []RegisterHotkeyArgs{
+				{Name: "close", Hotkey: "Alt+F4", Event: "hotkey:close"},
+			},
+			problems: []string{"close:reserved:"},
+		},
+		{
+			name: "in use",
+			set: []RegisterHotkeyArgs{
+				{Name: "taken", Hotkey: "Ctrl+Alt+U", Event: "hotkey:taken"},
+				{Name: "grabbed", Hotkey: "Ctrl+Alt+G Z", Event: "hotkey:grabbed"},
+				{Name: "broken", Hotkey: "Ctrl+Alt+B", Event: "hotkey:broken"},
+			},
+			problems: []string{"taken:in-use:", "grabbed:in-use:", "broken:register:"},
+		},
+	}
+
+	for _, tt := range tests {
+		h, backend, _ := newTestHotkeyService()
+		backend.Fail("Ctrl+Alt+U", errnoHotkeyAlreadyRegistered)
+		backend.Fail("Ctrl+Alt+G", ErrHotkeyInUse)
+		backend.Fail("Ctrl+Alt+B", errors.New("out of hotkeys"))
+
+		got := make([]string, 0)
+		for _, p := range h.ValidateHotkeys(tt.set) {
+			got = append(got, p.Name+":"+p.Kind+":"+p.Conflict)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tt.problems) {
+			t.Errorf("%s: problems = %v, want %v", tt.name, got, tt.problems)
+		}
+		// Probes are released straight away
+		assertRegistered(t, backend, 0)
+	}
+}
+
+func TestValidateHotkeysDoesNotHoldLock(t *testing.T) {
+	h, backend, _ := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	entered, release := backend.Block("Ctrl+Alt+V")
+
+	result := make(chan []HotkeyProblem)
+	go func() {
+		result <- h.ValidateHotkeys([]RegisterHotkeyArgs{
+			{Name: "vote", Hotkey: "Ctrl+Alt+V", Event: "hotkey:vote"},
+		})
+	}()
+	<-entered
+
+	// Registering needs h.mu while the probe is stuck in the OS
+	registered := make(chan error)
+	go func() {
+		registered <- h.RegisterHotkey(RegisterHotkeyArgs{Name: "vote", Hotkey: "Ctrl+Alt+V", Event: "hotkey:vote"})
+	}()
+	select {
+	case err := <-registered:
+		if err != nil {
+			t.Fatalf("RegisterHotkey: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RegisterHotkey waited for ValidateHotkeys to probe")
+	}
+
+	release()
+
+	// The probe then finds the combination taken, but by us
+	if problems := <-result; len(problems) != 0 {
+		t.Fatalf("ValidateHotkeys = %+v, want no problems", problems)
+	}
+}
+
+func TestChangeHotkeyRebinds(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+T", Event: "hotkey:toggle"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+Y"}); err != nil {
+		t.Fatalf("ChangeHotkey: %v", err)
+	}
+
+	if backend.Press("Ctrl+Alt+T") {
+		t.Fatal("the old combination is still registered")
+	}
+	backend.Press("Ctrl+Alt+Y")
+
+	data := events.waitFor(t, "hotkey:toggle", 1)[0].(HotkeyEventData)
+	if data.Name != "toggle" {
+		t.Fatalf("event name = %s, want toggle", data.Name)
+	}
+
+	// A combination that can't be registered keeps the previous one
+	backend.Fail("Ctrl+Alt+U", errnoHotkeyAlreadyRegistered)
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "toggle", Hotkey: "Ctrl+Alt+U"}); !errors.Is(err, ErrHotkeyInUse) {
+		t.Fatalf("ChangeHotkey: err = %v, want ErrHotkeyInUse", err)
+	}
+	if !backend.IsRegistered("Ctrl+Alt+Y") {
+		t.Fatal("the previous combination wasn't restored")
+	}
+
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "missing", Hotkey: "Ctrl+Alt+M"}); err == nil {
+		t.Fatal("ChangeHotkey of an unknown name succeeded")
+	}
+}
+
+func TestHotkeySequenceEmitsEvent(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "poll", Hotkey: "Ctrl+Alt+O P", Event: "hotkey:poll"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	// Follow-up keys are only grabbed while the sequence is pending
+	if backend.IsRegistered("C") {
+		t.Fatal("C is registered before the leader was pressed")
+	}
+
+	backend.Press("Ctrl+Alt+O")
+
+	pending := events.waitFor(t, EventHotkeySequencePending, 1)[0].(HotkeySequenceState)
+	if len(pending.Next) != 2 || pending.Next[0].Hotkey != "C" || pending.Next[1].Hotkey != "P" {
+		t.Fatalf("next keys = %+v, want C and P", pending.Next)
+	}
+
+	waitUntil(t, "C to be grabbed", func() bool { return backend.Press("C") })
+
+	data := events.waitFor(t, "hotkey:chat", 1)[0].(HotkeyEventData)
+	if data.Sequence != "Ctrl+Alt+O C" {
+		t.Fatalf("sequence = %s, want Ctrl+Alt+O C", data.Sequence)
+	}
+
+	end := events.waitFor(t, EventHotkeySequenceEnd, 1)[0].(HotkeySequenceState)
+	if end.Result != HotkeySequenceMatched || end.Name != "chat" {
+		t.Fatalf("sequence end = %+v, want matched chat", end)
+	}
+
+	waitUntil(t, "follow-up keys to be released", func() bool { return backend.Registered() == 1 })
+	if len(events.named("hotkey:poll")) != 0 {
+		t.Fatal("poll fired")
+	}
+}
+
+func TestHotkeySequenceFollowUpConflicts(t *testing.T) {
+	h, _, _ := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+C", Event: "hotkey:chat"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	// The follow-up couldn't be grabbed while chat holds it
+	err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "poll", Hotkey: "Ctrl+Alt+O Ctrl+Alt+C", Event: "hotkey:poll"})
+	var hkErr *HotkeyError
+	if !errors.As(err, &hkErr) || hkErr.Err != ErrHotkeyDuplicate || hkErr.Conflict != "chat" {
+		t.Fatalf("follow-up bound on its own: err = %v, want a duplicate of chat", err)
+	}
+
+	// The same the other way round
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "poll", Hotkey: "Ctrl+Alt+O Ctrl+Alt+P", Event: "hotkey:poll"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+	err = h.RegisterHotkey(RegisterHotkeyArgs{Name: "vote", Hotkey: "Ctrl+Alt+P", Event: "hotkey:vote"})
+	if !errors.Is(err, ErrHotkeyDuplicate) {
+		t.Fatalf("hotkey used as a follow-up: err = %v, want ErrHotkeyDuplicate", err)
+	}
+	if err := h.ChangeHotkey(ChangeHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+P"}); !errors.Is(err, ErrHotkeyDuplicate) {
+		t.Fatalf("ChangeHotkey to a follow-up: err = %v, want ErrHotkeyDuplicate", err)
+	}
+
+	err = h.RegisterHotkey(RegisterHotkeyArgs{Name: "echo", Hotkey: "Ctrl+Alt+E Ctrl+Alt+E", Event: "hotkey:echo"})
+	if !errors.Is(err, ErrHotkeyInvalid) {
+		t.Fatalf("sequence repeating its first key: err = %v, want ErrHotkeyInvalid", err)
+	}
+
+	problems := h.ValidateHotkeys([]RegisterHotkeyArgs{
+		{Name: "chat", Hotkey: "Ctrl+Alt+C", Event: "hotkey:chat"},
+		{Name: "poll", Hotkey: "Ctrl+Alt+O Ctrl+Alt+C", Event: "hotkey:poll"},
+	})
+	if len(problems) != 1 || problems[0].Kind != HotkeyProblemDuplicate || problems[0].Name != "poll" || problems[0].Conflict != "chat" {
+		t.Fatalf("ValidateHotkeys = %+v, want poll to duplicate chat", problems)
+	}
+}
+
+func TestHotkeySequenceTimeout(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.SetSequenceTimeout(50); err != nil {
+		t.Fatalf("SetSequenceTimeout: %v", err)
+	}
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	backend.Press("Ctrl+Alt+O")
+
+	pending := events.waitFor(t, EventHotkeySequencePending, 1)[0].(HotkeySequenceState)
+	if pending.TimeoutMs != 50 {
+		t.Fatalf("timeout = %dms, want 50ms", pending.TimeoutMs)
+	}
+
+	end := events.waitFor(t, EventHotkeySequenceEnd, 1)[0].(HotkeySequenceState)
+	if end.Result != HotkeySequenceTimeout {
+		t.Fatalf("sequence end = %+v, want a timeout", end)
+	}
+
+	waitUntil(t, "follow-up keys to be released", func() bool { return backend.Registered() == 1 })
+	if backend.Press("C") {
+		t.Fatal("C is still grabbed after the timeout")
+	}
+	if len(events.named("hotkey:chat")) != 0 {
+		t.Fatal("chat fired")
+	}
+}
+
+func TestHotkeySequenceEscapeCancels(t *testing.T) {
+	h, backend, events := newTestHotkeyService()
+	defer h.UnregisterAll()
+
+	if err := h.RegisterHotkey(RegisterHotkeyArgs{Name: "chat", Hotkey: "Ctrl+Alt+O C", Event: "hotkey:chat"}); err != nil {
+		t.Fatalf("RegisterHotkey: %v", err)
+	}
+
+	backend.Press("Ctrl+Alt+O")
+	events.waitFor(t, EventHotkeySequencePending, 1)
+
+	waitUntil(t, "Esc to be grabbed", func() bool { return backend.Press("Esc") })
+
+	end := events.waitFor(t, EventHotkeySequenceEnd, 1)[0].(HotkeySequenceState)
+	if end.Result != HotkeySequenceCancelled {
+		t.Fatalf("sequence end = %+v, want cancelled", end)
+	}
+
+	waitUntil(t, "follow-up keys to be released", func() bool { return backend.Registered() == 1 })
+	if len(events.named("hotkey:chat")) != 0 {
+		t.Fatal("chat fired")
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// Trigger modes for RegisterHotkeyArgs.Trigger
+const (
+	HotkeyTriggerPress     = "press"      // Fire when the hotkey goes down (default)
+	HotkeyTriggerRelease   = "release"    // Fire when the hotkey is released
+	HotkeyTriggerHold      = "hold"       // Fire after holding, and again on release
+	HotkeyTriggerDoubleTap = "double-tap" // Fire on a second press within the window
+)
+
+// Phases reported in HotkeyEventData
+const (
+	HotkeyPhaseDown      = "down"
+	HotkeyPhaseUp        = "up"
+	HotkeyPhaseHoldStart = "hold-start"
+	HotkeyPhaseHoldEnd   = "hold-end"
+	HotkeyPhaseDoubleTap = "double-tap"
+)
+
+const (
+	defaultHotkeyHold      = 300 * time.Millisecond
+	defaultHotkeyTapWindow = 400 * time.Millisecond
+)
+
+// HotkeyEventData is the payload emitted with a hotkey's event
+type HotkeyEventData struct {
+	Name     string `json:"name"`
+	Trigger  string `json:"trigger"`
+	Phase    string `json:"phase"`
+	HeldMs   int64  `json:"heldMs,omitempty"`   // How long the hotkey was held (up, hold-start, hold-end)
+	GapMs    int64  `json:"gapMs,omitempty"`    // Time between the two presses (double-tap)
+	Sequence string `json:"sequence,omitempty"` // The keys typed, for sequences such as "Ctrl+Alt+O C"
+	Time     int64  `json:"time"`               // Unix time in milliseconds
+}
+
+// hotkeyTrigger decides when a binding emits its event
+type hotkeyTrigger struct {
+	mode   string
+	hold   time.Duration
+	window time.Duration
+}
+
+// newHotkeyTrigger validates the trigger settings of RegisterHotkeyArgs
+func newHotkeyTrigger(mode string, holdMs, tapMs int) (hotkeyTrigger, error) {
+	t := hotkeyTrigger{
+		mode:   mode,
+		hold:   defaultHotkeyHold,
+		window: defaultHotkeyTapWindow,
+	}
+
+	if t.mode == "" {
+		t.mode = HotkeyTriggerPress
+	}
+
+	switch t.mode {
+	case HotkeyTriggerPress, HotkeyTriggerRelease, HotkeyTriggerHold, HotkeyTriggerDoubleTap:
+	default:
+		return t, fmt.Errorf("unknown hotkey trigger %q", mode)
+	}
+
+	if holdMs < 0 || tapMs < 0 {
+		return t, fmt.Errorf("hotkey timings can't be negative")
+	}
+	if holdMs > 0 {
+		t.hold = time.Duration(holdMs) * time.Millisecond
+	}
+	if tapMs > 0 {
+		t.window = time.Duration(tapMs) * time.Millisecond
+	}
+
+	return t, nil
+}
+
+// hotkeyTriggerState tracks presses for a single listener
+type hotkeyTriggerState struct {
+	trigger   hotkeyTrigger
+	pressedAt time.Time
+	lastTap   time.Time
+	holding   bool
+	holdTimer *time.Timer
+}
+
+// holdC returns the channel that fires when the hold duration is reached,
+// or nil when no hold is pending
+func (s *hotkeyTriggerState) holdC() <-chan time.Time {
+	if s.holdTimer == nil {
+		return nil
+	}
+	return s.holdTimer.C
+}
+
+// keydown handles a press and returns the phase to emit, if any
+func (s *hotkeyTriggerState) keydown(now time.Time) (HotkeyEventData, bool) {
+	s.pressedAt = now
+
+	switch s.trigger.mode {
+	case HotkeyTriggerPress:
+		return HotkeyEventData{Phase: HotkeyPhaseDown}, true
+
+	case HotkeyTriggerHold:
+		s.stopHoldTimer()
+		s.holdTimer = time.NewTimer(s.trigger.hold)
+
+	case HotkeyTriggerDoubleTap:
+		if !s.lastTap.IsZero() && now.Sub(s.lastTap) <= s.trigger.window {
+			gap := now.Sub(s.lastTap)
+			s.lastTap = time.Time{}
+			return HotkeyEventData{Phase: HotkeyPhaseDoubleTap, GapMs: gap.Milliseconds()}, true
+		}
+		s.lastTap = now
+	}
+
+	return HotkeyEventData{}, false
+}
+
+// keyup handles a release and returns the phase to emit, if any
+func (s *hotkeyTriggerState) keyup(now time.Time) (HotkeyEventData, bool) {
+	if s.pressedAt.IsZero() {
+		return HotkeyEventData{}, false
+	}
+	held := now.Sub(s.pressedAt)
+	s.pressedAt = time.Time{}
+
+	switch s.trigger.mode {
+	case HotkeyTriggerRelease:
+		return HotkeyEventData{Phase: HotkeyPhaseUp, HeldMs: held.Milliseconds()}, true
+
+	case HotkeyTriggerHold:
+		s.stopHoldTimer()
+		if s.holding {
+			s.holding = false
+			return HotkeyEventData{Phase: HotkeyPhaseHoldEnd, HeldMs: held.Milliseconds()}, true
+		}
+	}
+
+	return HotkeyEventData{}, false
+}
+
+// held handles the hold timer firing
+func (s *hotkeyTriggerState) held(now time.Time) (HotkeyEventData, bool) {
+	s.holdTimer = nil
+	if s.pressedAt.IsZero() {
+		return HotkeyEventData{}, false
+	}
+
+	s.holding = true
+	return HotkeyEventData{Phase: HotkeyPhaseHoldStart, HeldMs: now.Sub(s.pressedAt).Milliseconds()}, true
+}
+
+// stop ends a hold that is in progress when the listener shuts down, so the
+// frontend isn't left in the held state
+func (s *hotkeyTriggerState) stop(now time.Time) (HotkeyEventData, bool) {
+	s.stopHoldTimer()
+	if !s.holding {
+		return HotkeyEventData{}, false
+	}
+
+	s.holding = false
+	return HotkeyEventData{Phase: HotkeyPhaseHoldEnd, HeldMs: now.Sub(s.pressedAt).Milliseconds()}, true
+}
+
+func (s *hotkeyTriggerState) stopHoldTimer() {
+	if s.holdTimer != nil {
+		s.holdTimer.Stop()
+		s.holdTimer = nil
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// triggerStep is a call on hotkeyTriggerState at a time in milliseconds,
+// with the phase it should emit written as "phase:HeldMs:GapMs", or "" for
+// nothing
+type triggerStep struct {
+	call string // down, up, held or stop
+	at   int
+	want string
+}
+
+func TestHotkeyTriggerState(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger string
+		holdMs  int
+		tapMs   int
+		steps   []triggerStep
+	}{
+		{
+			name:    "press",
+			trigger: HotkeyTriggerPress,
+			steps: []triggerStep{
+				{"down", 0, "down:0:0"},
+				{"up", 50, ""},
+			},
+		},
+		{
+			name:    "release",
+			trigger: HotkeyTriggerRelease,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"up", 120, "up:120:0"},
+				{"up", 130, ""},
+			},
+		},
+		{
+			name:    "hold",
+			trigger: HotkeyTriggerHold,
+			holdMs:  200,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"held", 200, "hold-start:200:0"},
+				{"up", 750, "hold-end:750:0"},
+			},
+		},
+		{
+			name:    "released before the hold",
+			trigger: HotkeyTriggerHold,
+			holdMs:  200,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"up", 150, ""},
+				{"held", 200, ""}, // A timer that fired anyway
+			},
+		},
+		{
+			name:    "stopped while holding",
+			trigger: HotkeyTriggerHold,
+			holdMs:  200,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"held", 200, "hold-start:200:0"},
+				{"stop", 500, "hold-end:500:0"},
+				{"up", 600, ""},
+			},
+		},
+		{
+			name:    "stopped before the hold",
+			trigger: HotkeyTriggerHold,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"stop", 100, ""},
+			},
+		},
+		{
+			name:    "double-tap",
+			trigger: HotkeyTriggerDoubleTap,
+			tapMs:   300,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"up", 50, ""},
+				{"down", 250, "double-tap:0:250"},
+				{"up", 300, ""},
+				// A third tap starts over rather than firing again
+				{"down", 400, ""},
+				{"up", 450, ""},
+				{"down", 700, "double-tap:0:300"},
+			},
+		},
+		{
+			name:    "taps too far apart",
+			trigger: HotkeyTriggerDoubleTap,
+			tapMs:   300,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"down", 301, ""},
+				{"down", 700, ""},
+				{"down", 900, "double-tap:0:200"},
+			},
+		},
+		{
+			name:    "default tap window",
+			trigger: HotkeyTriggerDoubleTap,
+			steps: []triggerStep{
+				{"down", 0, ""},
+				{"down", 400, "double-tap:0:400"},
+			},
+		},
+	}
+
+	start := time.Now()
+	for _, tt := range tests {
+		trigger, err := newHotkeyTrigger(tt.trigger, tt.holdMs, tt.tapMs)
+		if err != nil {
+			t.Fatalf("%s: newHotkeyTrigger: %v", tt.name, err)
+		}
+		s := &hotkeyTriggerState{trigger: trigger}
+
+		for _, step := range tt.steps {
+			now := start.Add(time.Duration(step.at) * time.Millisecond)
+
+			var data HotkeyEventData
+			var ok bool
+			switch step.call {
+			case "down":
+				data, ok = s.keydown(now)
+			case "up":
+				data, ok = s.keyup(now)
+			case "held":
+				data, ok = s.held(now)
+			case "stop":
+				data, ok = s.stop(now)
+			}
+
+			got := ""
+			if ok {
+				got = fmt.Sprintf("%s:%d:%d", data.Phase, data.HeldMs, data.GapMs)
+			}
+			if got != step.want {
+				t.Errorf("%s: %s at %dms = %q, want %q", tt.name, step.call, step.at, got, step.want)
+			}
+		}
+
+		s.stopHoldTimer()
+	}
+}
+
+func TestNewHotkeyTrigger(t *testing.T) {
+	tests := []struct {
+		trigger string
+		holdMs  int
+		tapMs   int
+		ok      bool
+	}{
+		{"", 0, 0, true},
+		{HotkeyTriggerPress, 0, 0, true},
+		{HotkeyTriggerRelease, 0, 0, true},
+		{HotkeyTriggerHold, 500, 0, true},
+		{HotkeyTriggerDoubleTap, 0, 250, true},
+		{"Hold", 0, 0, false},
+		{"triple-tap", 0, 0, false},
+		{HotkeyTriggerHold, -1, 0, false},
+		{HotkeyTriggerDoubleTap, 0, -250, false},
+	}
+
+	for _, tt := range tests {
+		_, err := newHotkeyTrigger(tt.trigger, tt.holdMs, tt.tapMs)
+		if (err == nil) != tt.ok {
+			t.Errorf("newHotkeyTrigger(%q, %d, %d) = %v, want ok %v", tt.trigger, tt.holdMs, tt.tapMs, err, tt.ok)
+		}
+	}
+
+	trigger, _ := newHotkeyTrigger("", 0, 0)
+	if trigger.mode != HotkeyTriggerPress || trigger.hold != defaultHotkeyHold || trigger.window != defaultHotkeyTapWindow {
+		t.Fatalf("defaults = %+v", trigger)
+	}
+}
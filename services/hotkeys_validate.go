@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// Reasons a hotkey can be rejected. HotkeyError wraps one of these, so
+// callers can check them with errors.Is.
+var (
+	ErrHotkeyInvalid   = errors.New("invalid hotkey")
+	ErrHotkeyDuplicate = errors.New("hotkey is already bound to another action")
+	ErrHotkeyReserved  = errors.New("hotkey is reserved by the system")
+	ErrHotkeyInUse     = errors.New("hotkey is already registered by another application")
+	ErrHotkeyRegister  = errors.New("hotkey could not be registered")
+)
+
+// Problem kinds reported by ValidateHotkeys
+const (
+	HotkeyProblemInvalid   = "invalid"
+	HotkeyProblemDuplicate = "duplicate"
+	HotkeyProblemReserved  = "reserved"
+	HotkeyProblemInUse     = "in-use"
+	HotkeyProblemRegister  = "register"
+)
+
+// Windows' ERROR_HOTKEY_ALREADY_REGISTERED
+const errnoHotkeyAlreadyRegistered = syscall.Errno(1409)
+
+// Key combinations the OS keeps for itself, or which are too disruptive to
+// take over while a game is running
+var reservedHotkeys = []string{
+	"Ctrl+Alt+Delete",
+	"Ctrl+Shift+Esc",
+	"Ctrl+Esc",
+	"Alt+Tab",
+	"Alt+Shift+Tab",
+	"Alt+Esc",
+	"Alt+F4",
+	"Alt+Space",
+	"Alt+Enter",
+	"Win+D",
+	"Win+E",
+	"Win+G",
+	"Win+I",
+	"Win+L",
+	"Win+R",
+	"Win+S",
+	"Win+X",
+	"Win+Tab",
+	"Win+PrintScreen",
+	"Win+Shift+S",
+	"Win+Alt+R",
+	"Win+Alt+PrintScreen",
+	"F12", // Reserved for the debugger by RegisterHotKey
+	"PrintScreen",
+}
+
+var reservedHotkeySet = make(map[string]bool)
+
+func init() {
+	for _, s := range reservedHotkeys {
+		chord, err := ParseHotkey(s)
+		if err != nil {
+			panic(err)
+		}
+		reservedHotkeySet[chordID(chord)] = true
+	}
+}
+
+// HotkeyError is returned when a hotkey can't be registered. Err is one of
+// the ErrHotkey* values and Cause is the underlying OS error, if any.
+type HotkeyError struct {
+	Name     string
+	Hotkey   string
+	Conflict string // Name of the other action, for duplicates
+	Err      error
+	Cause    error
+}
+
+func (e *HotkeyError) Error() string {
+	msg := fmt.Sprintf("%s (%s): %v", e.Name, e.Hotkey, e.Err)
+	if e.Conflict != "" {
+		msg += " (used by " + e.Conflict + ")"
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *HotkeyError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.Cause}
+}
+
+// HotkeyProblem describes why a hotkey in a set can't be used
+type HotkeyProblem struct {
+	Name     string
+	Hotkey   string
+	Kind     string // One of the HotkeyProblem* kinds
+	Conflict string // Name of the other action, for duplicates
+	Message  string
+}
+
+// ValidateHotkeys checks a set of hotkeys before it is saved. It reports
+// invalid and reserved combinations, actions sharing the same combination,
+// and combinations that another application has already taken. The OS is
+// asked without holding h.mu, so a slow backend doesn't hold up hotkeys.
+func (h *HotkeyService) ValidateHotkeys(set []RegisterHotkeyArgs) []HotkeyProblem {
+	problems := make([]HotkeyProblem, 0)
+
+	h.mu.Lock()
+	owned := h.ownedChordsLocked()
+	h.mu.Unlock()
+
+	type accepted struct {
+		name  string
+		steps []HotkeyChord
+	}
+	seen := make([]accepted, 0, len(set))
+
+	for _, args := range set {
+		steps, err := resolveHotkeySequence(args.Hotkey, args.Modifiers, args.Key)
+		if err != nil {
+			problems = append(problems, hotkeyProblem(&HotkeyError{
+				Name:   args.Name,
+				Hotkey: args.Hotkey,
+				Err:    ErrHotkeyInvalid,
+				Cause:  err,
+			}))
+			continue
+		}
+
+		id := sequenceID(steps)
+		if _, err := newSequenceTrigger(steps, args.Trigger, args.HoldMs, args.TapMs); err != nil {
+			problems = append(problems, hotkeyProblem(&HotkeyError{
+				Name:   args.Name,
+				Hotkey: id,
+				Err:    ErrHotkeyInvalid,
+				Cause:  err,
+			}))
+			continue
+		}
+
+		command, err := newHotkeyCommand(args.Command)
+		if err == nil && args.Event == "" && command == nil {
+			err = fmt.Errorf("hotkey has no event or command")
+		}
+		if err != nil {
+			problems = append(problems, hotkeyProblem(&HotkeyError{
+				Name:   args.Name,
+				Hotkey: id,
+				Err:    ErrHotkeyInvalid,
+				Cause:  err,
+			}))
+			continue
+		}
+
+		conflict := ""
+		for _, other := range seen {
+			if sequencesConflict(steps, other.steps) || followUpConflict(steps, other.steps) {
+				conflict = other.name
+				break
+			}
+		}
+		if conflict != "" {
+			problems = append(problems, hotkeyProblem(&HotkeyError{
+				Name:     args.Name,
+				Hotkey:   id,
+				Conflict: conflict,
+				Err:      ErrHotkeyDuplicate,
+			}))
+			continue
+		}
+		seen = append(seen, accepted{name: args.Name, steps: steps})
+
+		if err := checkHotkeySequence(args.Name, steps); err != nil {
+			problems = append(problems, hotkeyProblem(err))
+			continue
+		}
+
+		// Only the first combination is held globally. Ones we already hold
+		// will be handed over on save.
+		if owned[chordID(steps[0])] {
+			continue
+		}
+
+		if err := h.probeHotkeyChord(args.Name, steps[0]); err != nil {
+			problems = append(problems, hotkeyProblem(err))
+		}
+	}
+
+	// A combination registered while probing is ours, not another
+	// application's
+	h.mu.Lock()
+	owned = h.ownedChordsLocked()
+	h.mu.Unlock()
+
+	merged := problems[:0]
+	for _, p := range problems {
+		if p.Kind != HotkeyProblemInUse || !owned[p.Hotkey] {
+			merged = append(merged, p)
+		}
+	}
+
+	return merged
+}
+
+// checkHotkeySequence rejects sequences with a combination that can never
+// be registered
+func checkHotkeySequence(name string, steps []HotkeyChord) error {
+	for _, chord := range steps {
+		if err := checkHotkeyChord(name, chord); err != nil {
+			return err
+		}
+	}
+
+	// The first combination is already held while the rest are grabbed
+	if hasFollowUp(steps, steps[0]) {
+		return &HotkeyError{
+			Name:   name,
+			Hotkey: sequenceID(steps),
+			Err:    ErrHotkeyInvalid,
+			Cause:  fmt.Errorf("%s can't be pressed again later in the sequence", chordID(steps[0])),
+		}
+	}
+
+	return nil
+}
+
+// checkHotkeyChord rejects combinations that can never be registered
+func checkHotkeyChord(name string, chord HotkeyChord) error {
+	if chord.Key == 0 {
+		return &HotkeyError{Name: name, Hotkey: chordID(chord), Err: ErrHotkeyInvalid}
+	}
+
+	if reservedHotkeySet[chordID(chord)] {
+		return &HotkeyError{Name: name, Hotkey: chordID(chord), Err: ErrHotkeyReserved}
+	}
+
+	return nil
+}
+
+// checkDuplicateLocked returns an error when another action already uses
+// the combination, one of the sequences starts with the other, or one has
+// a follow-up key the other starts with. h.mu must be held.
+func (h *HotkeyService) checkDuplicateLocked(name string, steps []HotkeyChord) error {
+	for other, b := range h.bindings {
+		if other != name && (sequencesConflict(b.steps, steps) || followUpConflict(b.steps, steps)) {
+			return &HotkeyError{Name: name, Hotkey: sequenceID(steps), Conflict: other, Err: ErrHotkeyDuplicate}
+		}
+	}
+	return nil
+}
+
+// ownedChordsLocked returns the combinations the service holds as hotkeys
+// or sequence leaders. h.mu must be held.
+func (h *HotkeyService) ownedChordsLocked() map[string]bool {
+	owned := make(map[string]bool, len(h.bindings))
+	for _, b := range h.bindings {
+		owned[chordID(b.steps[0])] = true
+	}
+	return owned
+}
+
+// probeHotkeyChord checks whether the OS will let us register a combination
+// by registering it and releasing it straight away
+func (h *HotkeyService) probeHotkeyChord(name string, chord HotkeyChord) error {
+	hk, err := h.backend.Register(chord)
+	if err != nil {
+		return registerHotkeyError(name, chord, err)
+	}
+	hk.Unregister()
+	return nil
+}
+
+// registerHotkeyError wraps an OS registration failure in a HotkeyError
+func registerHotkeyError(name string, chord HotkeyChord, err error) error {
+	kind := ErrHotkeyRegister
+	if errors.Is(err, errnoHotkeyAlreadyRegistered) || errors.Is(err, ErrHotkeyInUse) {
+		kind = ErrHotkeyInUse
+	}
+
+	return &HotkeyError{Name: name, Hotkey: chordID(chord), Err: kind, Cause: err}
+}
+
+// hotkeyProblem turns a validation error into a problem report
+func hotkeyProblem(err error) HotkeyProblem {
+	problem := HotkeyProblem{Message: err.Error()}
+
+	var hkErr *HotkeyError
+	if errors.As(err, &hkErr) {
+		problem.Name = hkErr.Name
+		problem.Hotkey = hkErr.Hotkey
+		problem.Conflict = hkErr.Conflict
+	}
+
+	switch {
+	case errors.Is(err, ErrHotkeyInvalid):
+		problem.Kind = HotkeyProblemInvalid
+	case errors.Is(err, ErrHotkeyDuplicate):
+		problem.Kind = HotkeyProblemDuplicate
+	case errors.Is(err, ErrHotkeyReserved):
+		problem.Kind = HotkeyProblemReserved
+	case errors.Is(err, ErrHotkeyInUse):
+		problem.Kind = HotkeyProblemInUse
+	default:
+		problem.Kind = HotkeyProblemRegister
+	}
+
+	return problem
+}
+
+// chordID returns a canonical name for a combination, so that e.g.
+// Alt+Ctrl+C and Ctrl+Alt+C compare equal
+func chordID(chord HotkeyChord) string {
+	return FormatHotkey(chord.Modifiers, chord.Key)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Monitor represents a display monitor with its properties.
+type Monitor struct {
+	ID       string       // Survives rearranging monitors, save this rather than the index
+	Device   string       // Name of the output, e.g. "\\.\DISPLAY1" or "DP-1"
+	Name     string       // Display name (e.g., "[1] 1920x1080")
+	Primary  bool         // The monitor with the taskbar or primary output
+	Width    int          // Width of the monitor
+	Height   int          // Height of the monitor
+	X        int          // X-coordinate of the monitor's position
+	Y        int          // Y-coordinate of the monitor's position
+	WorkArea WindowBounds // The monitor minus taskbars, panels and docks
+	Scale    float64      // DPI scale, 1 at 96 DPI
+}
+
+// MonitorSource enumerates the connected monitors. Each platform provides
+// its own; fakeMonitorSource is used in tests. Sources fill in everything
+// but Name.
+type MonitorSource interface {
+	Monitors() ([]Monitor, error)
+}
+
+// monitorID builds a Monitor.ID from the output the monitor is plugged into
+// and its model, e.g. "DP-1/DEL40F0"
+func monitorID(device, model string) string {
+	return device + "/" + model
+}
+
+// splitMonitorID returns the output and model of a Monitor.ID
+func splitMonitorID(id string) (device, model string) {
+	i := strings.LastIndex(id, "/")
+	if i < 0 {
+		return id, ""
+	}
+	return id[:i], id[i+1:]
+}
+
+// listMonitors enumerates the monitors left to right and names them, but
+// doesn't capture previews
+func (ws *WindowService) listMonitors() []Monitor {
+	monitors, err := ws.monitors.Monitors()
+	if err != nil {
+		fmt.Println("Failed to enumerate displays:", err)
+		return nil
+	}
+
+	sortMonitors(monitors)
+
+	for i := range monitors {
+		monitors[i].Name = fmt.Sprintf("[%d] %dx%d", i+1, monitors[i].Width, monitors[i].Height)
+		if monitors[i].Scale <= 0 {
+			monitors[i].Scale = 1
+		}
+	}
+
+	return monitors
+}
+
+// sortMonitors sorts monitors by position (Left, then Top)
+func sortMonitors(monitors []Monitor) {
+	sort.SliceStable(monitors, func(i, j int) bool {
+		if monitors[i].X != monitors[j].X {
+			return monitors[i].X < monitors[j].X
+		}
+		return monitors[i].Y < monitors[j].Y
+	})
+}
+
+// monitorAt returns the index of the monitor containing a point, or -1
+func monitorAt(monitors []Monitor, x, y int) int {
+	for i, m := range monitors {
+		if x >= m.X && x < m.X+m.Width && y >= m.Y && y < m.Y+m.Height {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveMonitor finds the monitor saved as id. When it is no longer
+// connected, the same model on another output is preferred, then whatever
+// is on the same output, then the primary monitor. It returns -1 if there
+// are no monitors at all.
+func resolveMonitor(monitors []Monitor, id string) int {
+	if len(monitors) == 0 {
+		return -1
+	}
+
+	device, model := splitMonitorID(id)
+	sameModel, sameDevice, primary := -1, -1, 0
+
+	for i, m := range monitors {
+		if m.ID == id {
+			return i
+		}
+
+		d, mo := splitMonitorID(m.ID)
+		if sameModel < 0 && model != "" && mo == model {
+			sameModel = i
+		}
+		if sameDevice < 0 && device != "" && d == device {
+			sameDevice = i
+		}
+		if m.Primary {
+			primary = i
+		}
+	}
+
+	switch {
+	case sameModel >= 0:
+		return sameModel
+	case sameDevice >= 0:
+		return sameDevice
+	default:
+		return primary
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import "sync"
+
+// fakeMonitorSource is an in-memory MonitorSource for tests. Plugging and
+// unplugging monitors is simulated with SetMonitors.
+type fakeMonitorSource struct {
+	mu       sync.Mutex
+	monitors []Monitor
+	fail     error
+}
+
+// newFakeMonitorSource returns a source with the given monitors connected
+func newFakeMonitorSource(monitors ...Monitor) *fakeMonitorSource {
+	return &fakeMonitorSource{monitors: monitors}
+}
+
+func (s *fakeMonitorSource) Monitors() ([]Monitor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail != nil {
+		return nil, s.fail
+	}
+
+	return append([]Monitor(nil), s.monitors...), nil
+}
+
+// SetMonitors replaces the connected monitors
+func (s *fakeMonitorSource) SetMonitors(monitors ...Monitor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.monitors = monitors
+}
+
+// Fail makes Monitors return err, or succeed again if err is nil
+func (s *fakeMonitorSource) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fail = err
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+)
+
+// Atoms read by x11Display.monitors
+var monitorAtoms = []string{"EDID", "_NET_WORKAREA", "RESOURCE_MANAGER"}
+
+// x11MonitorSource enumerates the outputs XRandR has a CRTC driving
+type x11MonitorSource struct{}
+
+func newMonitorSource() MonitorSource {
+	return x11MonitorSource{}
+}
+
+func (x11MonitorSource) Monitors() ([]Monitor, error) {
+	d, err := openX11Display(monitorAtoms...)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	return d.monitors()
+}
+
+// monitors lists the active XRandR outputs
+func (d *x11Display) monitors() ([]Monitor, error) {
+	if err := randr.Init(d.conn); err != nil {
+		return nil, fmt.Errorf("error initializing XRandR: %w", err)
+	}
+
+	resources, err := randr.GetScreenResourcesCurrent(d.conn, d.root).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("error getting screen resources: %w", err)
+	}
+
+	var primary randr.Output
+	if reply, err := randr.GetOutputPrimary(d.conn, d.root).Reply(); err == nil {
+		primary = reply.Output
+	}
+
+	workArea := d.workArea()
+	scale := d.scale()
+
+	var monitors []Monitor
+	for _, output := range resources.Outputs {
+		info, err := randr.GetOutputInfo(d.conn, output, resources.ConfigTimestamp).Reply()
+		if err != nil || info.Connection != randr.ConnectionConnected || info.Crtc == 0 {
+			continue
+		}
+
+		crtc, err := randr.GetCrtcInfo(d.conn, info.Crtc, resources.ConfigTimestamp).Reply()
+		if err != nil || crtc.Width == 0 || crtc.Height == 0 {
+			continue
+		}
+
+		device := string(info.Name)
+		m := Monitor{
+			ID:      monitorID(device, d.outputModel(output)),
+			Device:  device,
+			Primary: output == primary,
+			Width:   int(crtc.Width),
+			Height:  int(crtc.Height),
+			X:       int(crtc.X),
+			Y:       int(crtc.Y),
+			Scale:   scale,
+		}
+		m.WorkArea = intersectBounds(WindowBounds{X: m.X, Y: m.Y, Width: m.Width, Height: m.Height}, workArea)
+
+		monitors = append(monitors, m)
+	}
+
+	// Without a primary output, the first one is treated as primary
+	if primary == 0 && len(monitors) > 0 {
+		monitors[0].Primary = true
+	}
+
+	return monitors, nil
+}
+
+// outputModel reads the manufacturer and product code from an output's
+// EDID, in the same form Windows uses, e.g. "DEL40F0"
+func (d *x11Display) outputModel(output randr.Output) string {
+	reply, err := randr.GetOutputProperty(d.conn, output, d.atoms["EDID"], xproto.AtomAny, 0, 32, false, false).Reply()
+	if err != nil || len(reply.Data) < 12 {
+		return ""
+	}
+	edid := reply.Data
+
+	// Three letters packed into five bits each, big endian
+	id := uint16(edid[8])<<8 | uint16(edid[9])
+	maker := []byte{
+		byte('A' - 1 + (id>>10)&0x1F),
+		byte('A' - 1 + (id>>5)&0x1F),
+		byte('A' - 1 + id&0x1F),
+	}
+	product := uint16(edid[10]) | uint16(edid[11])<<8
+
+	return fmt.Sprintf("%s%04X", maker, product)
+}
+
+// workArea returns the desktop minus panels and docks, from the EWMH
+// _NET_WORKAREA of the first desktop. It is empty if the window manager
+// doesn't set it.
+func (d *x11Display) workArea() WindowBounds {
+	area := d.atomList(d.root, d.atoms["_NET_WORKAREA"], xproto.AtomCardinal)
+	if len(area) < 4 {
+		return WindowBounds{}
+	}
+	return WindowBounds{X: int(area[0]), Y: int(area[1]), Width: int(area[2]), Height: int(area[3])}
+}
+
+// scale returns the factor GTK scales windows by. X11 has a single scale
+// for every monitor: GDK_SCALE when it is set, otherwise the whole part of
+// the Xft.dpi resource over 96, which is how desktops set it.
+func (d *x11Display) scale() float64 {
+	if scale, err := strconv.Atoi(os.Getenv("GDK_SCALE")); err == nil && scale > 0 {
+		return float64(scale)
+	}
+
+	resources := string(d.property(d.root, d.atoms["RESOURCE_MANAGER"], xproto.AtomString))
+	for _, line := range strings.Split(resources, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) != "Xft.dpi" {
+			continue
+		}
+		if dpi, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && dpi >= 96 {
+			return math.Floor(dpi / 96)
+		}
+	}
+	return 1
+}
+
+// intersectBounds returns the part of a inside b, or a itself if b is
+// empty or they don't overlap
+func intersectBounds(a, b WindowBounds) WindowBounds {
+	if b.Width <= 0 || b.Height <= 0 {
+		return a
+	}
+
+	left, top := max(a.X, b.X), max(a.Y, b.Y)
+	right, bottom := min(a.X+a.Width, b.X+b.Width), min(a.Y+a.Height, b.Y+b.Height)
+	if right <= left || bottom <= top {
+		return a
+	}
+
+	return WindowBounds{X: left, Y: top, Width: right - left, Height: bottom - top}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/jpeg"
+	"sync"
+	"time"
+)
+
+const (
+	previewMaxWidth  = 320             // Previews are scaled down to fit
+	previewMaxHeight = 180             // within previewMaxWidth x previewMaxHeight
+	previewTTL       = 5 * time.Second // How long a preview is reused
+)
+
+// previewCache keeps recent monitor previews by monitor ID
+type previewCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedPreview
+}
+
+type cachedPreview struct {
+	monitor Monitor
+	preview string
+	taken   time.Time
+}
+
+// GetMonitorPreview returns a small JPEG data URI of what is on a monitor.
+// Previews are cached for a few seconds, so a display picker can ask for
+// every monitor without capturing the screen each time.
+func (ws *WindowService) GetMonitorPreview(id string) (string, error) {
+	var monitor Monitor
+	found := false
+	for _, m := range ws.listMonitors() {
+		if m.ID == id {
+			monitor, found = m, true
+			break
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("monitor %s not found", id)
+	}
+
+	c := &ws.previews
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A preview is only reused while the monitor keeps its position and size
+	if entry, ok := c.entries[id]; ok && entry.monitor == monitor && time.Since(entry.taken) < previewTTL {
+		return entry.preview, nil
+	}
+
+	width, height := previewSize(monitor.Width, monitor.Height)
+	img, err := ws.captureScreen(monitor.X, monitor.Y, monitor.Width, monitor.Height, width, height)
+	if err != nil {
+		return "", fmt.Errorf("error capturing monitor %s: %w", id, err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return "", fmt.Errorf("error encoding preview: %w", err)
+	}
+	preview := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	if c.entries == nil {
+		c.entries = make(map[string]cachedPreview)
+	}
+	c.entries[id] = cachedPreview{monitor: monitor, preview: preview, taken: time.Now()}
+
+	return preview, nil
+}
+
+// previewSize scales a monitor down to fit the preview size, keeping its
+// aspect ratio
+func previewSize(width, height int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return 1, 1
+	}
+
+	scale := min(float64(previewMaxWidth)/float64(width), float64(previewMaxHeight)/float64(height), 1)
+	return max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+}
+
+// bgraToRGBA swaps the blue and red bytes of every pixel in place. The
+// alpha byte is made opaque, as screen captures leave it undefined.
+func bgraToRGBA(pix []byte) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		pix[i], pix[i+2], pix[i+3] = pix[i+2], pix[i], 0xFF
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPreviewSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{1920, 1080, 320, 180},
+		{2560, 1440, 320, 180},
+		{3440, 1440, 320, 133}, // Ultrawide is limited by width
+		{1080, 1920, 101, 180}, // Portrait is limited by height
+		{320, 180, 320, 180},
+		{200, 100, 200, 100}, // Small monitors aren't scaled up
+		{32000, 10, 320, 1},
+		{10, 32000, 1, 180},
+		{0, 0, 1, 1},
+		{-1920, 1080, 1, 1},
+	}
+
+	for _, tt := range tests {
+		w, h := previewSize(tt.width, tt.height)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("previewSize(%d, %d) = %dx%d, want %dx%d", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestBGRAToRGBA(t *testing.T) {
+	pix := []byte{
+		0x10, 0x20, 0x30, 0x00, // Blue, green, red and an undefined alpha
+		0xFF, 0x00, 0x00, 0x7F,
+		0x00, 0x00, 0xFF, 0xFF,
+		0x01, 0x02, // A partial pixel is left alone
+	}
+	want := []byte{
+		0x30, 0x20, 0x10, 0xFF,
+		0x00, 0x00, 0xFF, 0xFF,
+		0xFF, 0x00, 0x00, 0xFF,
+		0x01, 0x02,
+	}
+
+	bgraToRGBA(pix)
+	if !bytes.Equal(pix, want) {
+		t.Fatalf("bgraToRGBA = % X, want % X", pix, want)
+	}
+}
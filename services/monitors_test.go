@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+var testMonitors = []Monitor{
+	{ID: "DP-2/GSM5B08", Device: "DP-2", Width: 2560, Height: 1440, X: 1920, Y: 0, Scale: 1.25},
+	{ID: "DP-1/DEL40F0", Device: "DP-1", Primary: true, Width: 1920, Height: 1080, X: 0, Y: 0},
+	{ID: "HDMI-1/SAM0F99", Device: "HDMI-1", Width: 1920, Height: 1080, X: -1920, Y: 0, Scale: 1},
+}
+
+func TestGetMonitorsSortsAndNames(t *testing.T) {
+	ws := newWindowService(WindowModeOverlay, newFakeMonitorSource(testMonitors...))
+
+	monitors := ws.GetMonitors()
+	if len(monitors) != 3 {
+		t.Fatalf("got %d monitors, want 3", len(monitors))
+	}
+
+	want := []struct {
+		id, name string
+		scale    float64
+	}{
+		{"HDMI-1/SAM0F99", "[1] 1920x1080", 1},
+		{"DP-1/DEL40F0", "[2] 1920x1080", 1},
+		{"DP-2/GSM5B08", "[3] 2560x1440", 1.25},
+	}
+	for i, w := range want {
+		m := monitors[i]
+		if m.ID != w.id || m.Name != w.name || m.Scale != w.scale {
+			t.Errorf("monitor %d = %s %q scale %g, want %s %q scale %g", i, m.ID, m.Name, m.Scale, w.id, w.name, w.scale)
+		}
+	}
+}
+
+func TestGetMonitorsFollowsSource(t *testing.T) {
+	source := newFakeMonitorSource(testMonitors...)
+	ws := newWindowService(WindowModeOverlay, source)
+
+	source.SetMonitors(testMonitors[1])
+	if monitors := ws.GetMonitors(); len(monitors) != 1 || monitors[0].ID != "DP-1/DEL40F0" {
+		t.Fatalf("monitors after unplugging = %+v, want only DP-1", monitors)
+	}
+
+	source.Fail(errors.New("no display"))
+	if monitors := ws.GetMonitors(); monitors != nil {
+		t.Fatalf("monitors on error = %+v, want nil", monitors)
+	}
+	if _, err := ws.MoveMainWindowToDisplay("DP-1/DEL40F0"); err == nil {
+		t.Fatal("MoveMainWindowToDisplay succeeded without monitors")
+	}
+}
+
+func TestResolveMonitor(t *testing.T) {
+	monitors := append([]Monitor(nil), testMonitors...)
+	sortMonitors(monitors)
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"DP-2/GSM5B08", "DP-2/GSM5B08"},
+		{"DP-3/GSM5B08", "DP-2/GSM5B08"},     // Same model on another output
+		{"HDMI-1/ACR0001", "HDMI-1/SAM0F99"}, // Another model on the same output
+		{"DP-9/UNKNOWN", "DP-1/DEL40F0"},     // The primary monitor
+		{"", "DP-1/DEL40F0"},
+	}
+	for _, tt := range tests {
+		i := resolveMonitor(monitors, tt.id)
+		if i < 0 || monitors[i].ID != tt.want {
+			t.Errorf("resolveMonitor(%q) = %d, want %s", tt.id, i, tt.want)
+		}
+	}
+
+	if i := resolveMonitor(nil, "DP-1/DEL40F0"); i != -1 {
+		t.Errorf("resolveMonitor without monitors = %d, want -1", i)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type MonitorInfoEx struct {
+	CbSize    uint32
+	RcMonitor windows.Rect
+	RcWork    windows.Rect
+	DwFlags   uint32
+	Device    [32]uint16 // Display device name (WCHAR[32])
+}
+
+// DisplayDevice is DISPLAY_DEVICEW
+type DisplayDevice struct {
+	Cb           uint32
+	DeviceName   [32]uint16
+	DeviceString [128]uint16
+	StateFlags   uint32
+	DeviceID     [128]uint16
+	DeviceKey    [128]uint16
+}
+
+// Load GetMonitorInfo from user32.dll
+var (
+	user32Window            = syscall.NewLazyDLL("user32.dll")
+	procEnumDisplayMonitors = user32Window.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfo      = user32Window.NewProc("GetMonitorInfoW")
+	procEnumDisplayDevices  = user32Window.NewProc("EnumDisplayDevicesW")
+	shcore                  = windows.NewLazySystemDLL("shcore.dll")
+	procGetDpiForMonitor    = shcore.NewProc("GetDpiForMonitor")
+)
+
+const (
+	MONITORINFOF_PRIMARY          = 0x1
+	EDD_GET_DEVICE_INTERFACE_NAME = 0x1
+	MDT_EFFECTIVE_DPI             = 0
+)
+
+// monitorEnumCallback is created once, as Windows callbacks are never
+// freed. It adds each monitor to enumeratedMonitors.
+var (
+	enumeratedMonitors   []Monitor
+	enumeratedMonitorsMu sync.Mutex
+	monitorEnumCallback  = syscall.NewCallback(func(hMonitor windows.Handle, hdcMonitor windows.Handle, lprcMonitor *windows.Rect, dwData uintptr) uintptr {
+		if m, ok := describeMonitor(hMonitor); ok {
+			enumeratedMonitors = append(enumeratedMonitors, m)
+		}
+		return 1 // Continue enumeration
+	})
+)
+
+// winMonitorSource enumerates monitors with EnumDisplayMonitors
+type winMonitorSource struct{}
+
+func newMonitorSource() MonitorSource {
+	return winMonitorSource{}
+}
+
+func (winMonitorSource) Monitors() ([]Monitor, error) {
+	enumeratedMonitorsMu.Lock()
+	defer enumeratedMonitorsMu.Unlock()
+
+	enumeratedMonitors = nil
+
+	r1, _, err := procEnumDisplayMonitors.Call(0, 0, monitorEnumCallback, 0)
+	if r1 == 0 {
+		return nil, fmt.Errorf("EnumDisplayMonitors failed: %v", err)
+	}
+
+	monitors := enumeratedMonitors
+	enumeratedMonitors = nil
+
+	return monitors, nil
+}
+
+// describeMonitor gathers everything Monitor needs about a monitor
+func describeMonitor(hMonitor windows.Handle) (Monitor, bool) {
+	var mi MonitorInfoEx
+	mi.CbSize = uint32(unsafe.Sizeof(mi))
+
+	// Call GetMonitorInfo via Windows API
+	r1, _, _ := procGetMonitorInfo.Call(uintptr(hMonitor), uintptr(unsafe.Pointer(&mi)))
+	if r1 == 0 {
+		return Monitor{}, false
+	}
+
+	device := syscall.UTF16ToString(mi.Device[:])
+
+	return Monitor{
+		ID:      monitorID(device, monitorModel(mi.Device[:])),
+		Device:  device,
+		Primary: mi.DwFlags&MONITORINFOF_PRIMARY != 0,
+		Width:   int(mi.RcMonitor.Right - mi.RcMonitor.Left),
+		Height:  int(mi.RcMonitor.Bottom - mi.RcMonitor.Top),
+		X:       int(mi.RcMonitor.Left),
+		Y:       int(mi.RcMonitor.Top),
+		WorkArea: WindowBounds{
+			X:      int(mi.RcWork.Left),
+			Y:      int(mi.RcWork.Top),
+			Width:  int(mi.RcWork.Right - mi.RcWork.Left),
+			Height: int(mi.RcWork.Bottom - mi.RcWork.Top),
+		},
+		Scale: monitorScale(hMonitor),
+	}, true
+}
+
+// monitorModel returns the model of the monitor attached to a display
+// device, e.g. "DEL40F0", from its device interface name
+// "\\?\DISPLAY#DEL40F0#5&2c1b1f8d&0&UID4353#{e6f07b5f-...}"
+func monitorModel(device []uint16) string {
+	var dd DisplayDevice
+	dd.Cb = uint32(unsafe.Sizeof(dd))
+
+	r1, _, _ := procEnumDisplayDevices.Call(uintptr(unsafe.Pointer(&device[0])), 0,
+		uintptr(unsafe.Pointer(&dd)), EDD_GET_DEVICE_INTERFACE_NAME)
+	if r1 == 0 {
+		return ""
+	}
+
+	parts := strings.Split(syscall.UTF16ToString(dd.DeviceID[:]), "#")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// monitorScale returns the DPI scale of a monitor, or 1 before Windows 8.1
+func monitorScale(hMonitor windows.Handle) float64 {
+	if procGetDpiForMonitor.Find() != nil {
+		return 1
+	}
+
+	var dpiX, dpiY uint32
+	ret, _, _ := procGetDpiForMonitor.Call(uintptr(hMonitor), MDT_EFFECTIVE_DPI,
+		uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY)))
+	if ret != 0 || dpiX == 0 {
+		return 1
+	}
+	return float64(dpiX) / 96
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/events"
+)
+
+// EventOverlaySocket is emitted with {data} for every message the main
+// overlay receives from Smash Soda, so that overlays on other monitors,
+// which have no connection of their own, see it too
+const EventOverlaySocket = "overlay:socket"
+
+// overlayWindow is an extra overlay on a monitor other than the main one.
+// Widgets are shown on it when their display is set to its monitor ID.
+type overlayWindow struct {
+	window  *application.WebviewWindow
+	placed  Monitor     // The monitor the window was last placed on
+	shown   bool        // False while its monitor is unplugged
+	display DisplayInfo // Last display:changed sent for it
+}
+
+// SetOverlayDisplays opens an extra overlay on each of the given monitors,
+// alongside the main one, and closes any others. The monitors the extra
+// overlays are on are returned. An overlay whose monitor is unplugged is
+// hidden until it is plugged in again.
+func (ws *WindowService) SetOverlayDisplays(ids []string) []Monitor {
+	if WailsApp == nil {
+		return nil
+	}
+
+	monitors := ws.listMonitors()
+
+	ws.moveMu.Lock()
+	defer ws.refreshHitRegions()
+
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		if id == "" || wanted[id] {
+			continue
+		}
+		wanted[id] = true
+
+		if _, ok := ws.overlays[id]; !ok {
+			ws.overlays[id] = &overlayWindow{window: ws.newOverlayWindow(id)}
+			fmt.Printf("Opened overlay for %s\n", id)
+		}
+	}
+
+	// Closed once moveMu is released, as the closing hook takes it
+	var closing []*application.WebviewWindow
+	for id, o := range ws.overlays {
+		if !wanted[id] {
+			if err := setHitRegions(o.window, o.placed, nil); err != nil {
+				fmt.Println("Error clearing hit regions:", err)
+			}
+			closing = append(closing, o.window)
+			delete(ws.overlays, id)
+			fmt.Printf("Closed overlay for %s\n", id)
+		}
+	}
+
+	placed := ws.placeOverlaysLocked(monitors)
+	ws.moveMu.Unlock()
+
+	for _, w := range closing {
+		w.Close()
+	}
+
+	return placed
+}
+
+// GetOverlayDisplay returns the monitor an overlay is on. An empty ID is
+// the main overlay.
+func (ws *WindowService) GetOverlayDisplay(id string) DisplayInfo {
+	if id == "" {
+		return ws.GetDisplay()
+	}
+
+	ws.moveMu.Lock()
+	defer ws.moveMu.Unlock()
+
+	if o, ok := ws.overlays[id]; ok {
+		return o.display
+	}
+	return DisplayInfo{Overlay: id}
+}
+
+// BroadcastSocketMessage passes a message from Smash Soda on to the other
+// windows and the scene rules. It is called by the main overlay, which
+// owns the connection.
+func (ws *WindowService) BroadcastSocketMessage(message string) {
+	emitAppEvent(EventOverlaySocket, map[string]interface{}{
+		"data": message,
+	})
+
+	ws.socketHandler.handle(message)
+}
+
+func (ws *WindowService) handleSocketMessages(handler socketHandler) {
+	ws.socketHandler = handler
+}
+
+// SendSocketMessage asks the main overlay to send a message to Smash Soda,
+// for overlays that have no connection of their own
+func (ws *WindowService) SendSocketMessage(event string, data interface{}) {
+	emitAppEvent(EventSocketSend, &HotkeyCommand{Event: event, Data: data})
+}
+
+// newOverlayWindow creates a hidden overlay window. It is shown once it
+// has been placed on its monitor.
+func (ws *WindowService) newOverlayWindow(id string) *application.WebviewWindow {
+	w := WailsApp.Window.NewWithOptions(application.WebviewWindowOptions{
+		Name:                       "overlay:" + id,
+		Title:                      "Smash Glass",
+		BackgroundType:             application.BackgroundTypeTransparent,
+		BackgroundColour:           application.NewRGBA(0, 0, 0, 0),
+		URL:                        "/?mode=overlay&display=" + url.QueryEscape(id),
+		Zoom:                       1,
+		ZoomControlEnabled:         true,
+		DefaultContextMenuDisabled: false,
+		Frameless:                  ws.GetMode() == WindowModeBorderless,
+		AlwaysOnTop:                ws.overlayMode(),
+		IgnoreMouseEvents:          ws.overlayMode(),
+		DevToolsEnabled:            true,
+		Hidden:                     true,
+	})
+
+	ws.forgetOverlayOnClose(w, id)
+	return w
+}
+
+// forgetOverlayOnClose drops an extra overlay once its window closes, such
+// as when the user closes it, so that it isn't moved or shown again
+func (ws *WindowService) forgetOverlayOnClose(w *application.WebviewWindow, id string) {
+	w.RegisterHook(events.Common.WindowClosing, func(e *application.WindowEvent) {
+		ws.moveMu.Lock()
+		o, ok := ws.overlays[id]
+		if ok && o.window == w {
+			delete(ws.overlays, id)
+		}
+		ws.moveMu.Unlock()
+
+		if ok && o.window == w {
+			fmt.Printf("Closed overlay for %s\n", id)
+			ws.refreshHitRegions()
+		}
+	})
+}
+
+// placeOverlaysLocked moves the extra overlays onto their monitors. A
+// monitor is never shared: overlays whose monitor is connected keep it,
+// and an overlay whose monitor is missing or taken is hidden. ws.moveMu
+// must be held.
+func (ws *WindowService) placeOverlaysLocked(monitors []Monitor) []Monitor {
+	ids := make([]string, 0, len(ws.overlays))
+	for id := range ws.overlays {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Monitor ID -> the overlay on it, where "" is the main overlay
+	taken := map[string]string{}
+	if ws.placed.ID != "" {
+		taken[ws.placed.ID] = ""
+	}
+	for _, m := range monitors {
+		if _, ok := ws.overlays[m.ID]; ok {
+			if _, ok := taken[m.ID]; !ok {
+				taken[m.ID] = m.ID
+			}
+		}
+	}
+
+	overlay := ws.overlayMode()
+
+	var placed []Monitor
+	for _, id := range ids {
+		o := ws.overlays[id]
+
+		index := overlayMonitor(monitors, id)
+		if index >= 0 {
+			if owner, ok := taken[monitors[index].ID]; ok && owner != id {
+				index = -1
+			}
+		}
+
+		if index < 0 {
+			if o.shown {
+				o.window.Hide()
+				o.shown = false
+				fmt.Printf("Display %s is not free, hiding its overlay\n", id)
+			}
+			continue
+		}
+
+		monitor := monitors[index]
+		taken[monitor.ID] = id
+		placed = append(placed, monitor)
+
+		if o.shown && o.placed == monitor {
+			continue
+		}
+
+		// Fullscreen windows can't be moved
+		if o.shown && overlay {
+			o.window.UnFullscreen()
+		}
+
+		if err := placeWindow(o.window, monitor); err != nil {
+			fmt.Println("Error moving overlay:", err)
+		}
+
+		if overlay {
+			o.window.Fullscreen()
+			o.window.SetAlwaysOnTop(true)
+		}
+		o.window.Show()
+
+		o.shown = true
+		o.placed = monitor
+
+		fmt.Printf("Moved overlay for %s to %s (%d x %d) (x: %d, y: %d)\n", id, monitor.Name, monitor.Width, monitor.Height, monitor.X, monitor.Y)
+
+		info := displayInfo(monitor)
+		info.Overlay = id
+		if o.display != info {
+			o.display = info
+			emitAppEvent(EventDisplayChanged, info)
+		}
+	}
+
+	return placed
+}
+
+// overlayMonitor finds the monitor for an extra overlay, or -1. Unlike the
+// main overlay, it never falls back to the primary monitor, where its
+// widgets would cover the game.
+func overlayMonitor(monitors []Monitor, id string) int {
+	index := resolveMonitor(monitors, id)
+	if index < 0 {
+		return -1
+	}
+
+	device, model := splitMonitorID(id)
+	d, m := splitMonitorID(monitors[index].ID)
+	if monitors[index].ID == id || (model != "" && m == model) || d == device {
+		return index
+	}
+	return -1
+}
+
+// overlayWindows returns the main overlay and the extra overlays that are
+// shown
+func (ws *WindowService) overlayWindows() []*application.WebviewWindow {
+	var windows []*application.WebviewWindow
+	if WailsWindow != nil {
+		windows = append(windows, WailsWindow)
+	}
+
+	ws.moveMu.Lock()
+	defer ws.moveMu.Unlock()
+
+	for _, o := range ws.overlays {
+		if o.shown {
+			windows = append(windows, o.window)
+		}
+	}
+	return windows
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/mitchellh/go-ps"
+)
+
+// How often to check whether terminated processes have exited
+const processPollInterval = 50 * time.Millisecond
+
+// TerminateProcessTree asks a process and all of its descendants to exit
+// (SIGTERM on Unix, WM_CLOSE on Windows) and kills the ones still running
+// after grace. A grace of zero kills them straight away. Every process is
+// tried, and the errors are returned joined together.
+func TerminateProcessTree(pid int, grace time.Duration) error {
+	pids, err := processTree(pid)
+	if err != nil {
+		return err
+	}
+
+	return terminateProcesses(pids, grace)
+}
+
+// processTree returns pid followed by all of its descendants. The tree is
+// read up front, as children are re-parented once their parent exits.
+func processTree(pid int) ([]int, error) {
+	p, err := ps.FindProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("error finding process %d: %w", pid, err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+
+	processes, err := ps.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("error listing processes: %w", err)
+	}
+
+	children := make(map[int][]int)
+	for _, proc := range processes {
+		children[proc.PPid()] = append(children[proc.PPid()], proc.Pid())
+	}
+
+	tree := []int{pid}
+	for i := 0; i < len(tree); i++ {
+		for _, child := range children[tree[i]] {
+			if child != pid { // PID 0 can be its own parent
+				tree = append(tree, child)
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// terminateProcesses asks the processes to exit, waits up to grace for
+// them to do so and kills the rest
+func terminateProcesses(pids []int, grace time.Duration) error {
+	var errs []error
+
+	if grace > 0 {
+		if err := askToExit(pids); err != nil {
+			errs = append(errs, err)
+		}
+
+		deadline := time.Now().Add(grace)
+		for anyRunning(pids) && time.Now().Before(deadline) {
+			time.Sleep(processPollInterval)
+		}
+	}
+
+	for _, pid := range pids {
+		if !processRunning(pid) {
+			continue
+		}
+		if err := killProcess(pid); err != nil && processRunning(pid) {
+			errs = append(errs, fmt.Errorf("error killing process %d: %w", pid, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func anyRunning(pids []int) bool {
+	for _, pid := range pids {
+		if processRunning(pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessTree is a process started by StartProcessTree. Everything it
+// starts is kept in one process group (Unix) or job object (Windows), so
+// the whole tree can be stopped even after some of it has been orphaned.
+type ProcessTree struct {
+	cmd   *exec.Cmd
+	group *processGroup
+	done  chan struct{}
+	err   error
+}
+
+// StartProcessTree starts cmd in its own process group or job object. On
+// Windows it is started suspended and only resumed once it is in the job,
+// so nothing it starts can escape. Use the returned ProcessTree to wait for
+// it rather than cmd.Wait.
+func StartProcessTree(cmd *exec.Cmd) (*ProcessTree, error) {
+	prepareProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting %s: %w", cmd.Path, err)
+	}
+
+	group, err := newProcessGroup(cmd.Process.Pid)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("error creating process group for %s: %w", cmd.Path, err)
+	}
+
+	t := &ProcessTree{
+		cmd:   cmd,
+		group: group,
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		t.err = t.cmd.Wait()
+		close(t.done)
+	}()
+
+	return t, nil
+}
+
+// Pid returns the process ID of the started process
+func (t *ProcessTree) Pid() int {
+	return t.cmd.Process.Pid
+}
+
+// Wait waits for the started process to exit
+func (t *ProcessTree) Wait() error {
+	<-t.done
+	return t.err
+}
+
+// Terminate stops the started process and everything it started, giving
+// them grace to exit before they are killed
+func (t *ProcessTree) Terminate(grace time.Duration) error {
+	var errs []error
+
+	// The started process may have exited already, leaving only the group
+	select {
+	case <-t.done:
+	default:
+		pids, err := processTree(t.Pid())
+		if err == nil {
+			err = terminateProcesses(pids, grace)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Kill whatever is left, including orphans that were missed above
+	if err := t.group.kill(); err != nil {
+		errs = append(errs, fmt.Errorf("error killing process group: %w", err))
+	}
+
+	<-t.done
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+//go:build !windows
+
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// processGroup is the process group a ProcessTree was started in
+type processGroup struct {
+	pgid int
+}
+
+// prepareProcessGroup makes cmd start in a new process group
+func prepareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// newProcessGroup returns the group led by pid, started by
+// prepareProcessGroup
+func newProcessGroup(pid int) (*processGroup, error) {
+	return &processGroup{pgid: pid}, nil
+}
+
+// kill sends SIGKILL to every process left in the group
+func (g *processGroup) kill() error {
+	if err := syscall.Kill(-g.pgid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// askToExit sends SIGTERM to each process
+func askToExit(pids []int) error {
+	var errs []error
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			errs = append(errs, fmt.Errorf("error stopping process %d: %w", pid, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// killProcess sends SIGKILL to a process
+func killProcess(pid int) error {
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// processRunning reports whether a process exists and isn't a zombie
+// waiting to be reaped
+func processRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+
+	// The state follows the command name, which is in parentheses
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+	return true
+}
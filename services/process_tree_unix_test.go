@@ -0,0 +1,136 @@
+//go:build !windows
+
+package services
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-ps"
+)
+
+// startTestProcessTree runs a shell script with StartProcessTree and waits
+// until sleeps of its process group are running
+func startTestProcessTree(t *testing.T, script string, sleeps int) *ProcessTree {
+	t.Helper()
+
+	tree, err := StartProcessTree(exec.Command("sh", "-c", script))
+	if err != nil {
+		t.Fatalf("StartProcessTree: %v", err)
+	}
+	t.Cleanup(func() { tree.Terminate(0) })
+
+	waitUntil(t, "the script to start its sleeps", func() bool {
+		running := 0
+		for _, p := range groupProcesses(t, tree.Pid()) {
+			if p.Executable() == "sleep" {
+				running++
+			}
+		}
+		return running >= sleeps
+	})
+
+	return tree
+}
+
+// groupProcesses returns the running processes in a process group
+func groupProcesses(t *testing.T, pgid int) []ps.Process {
+	t.Helper()
+
+	processes, err := ps.Processes()
+	if err != nil {
+		t.Fatalf("listing processes: %v", err)
+	}
+
+	group := make([]ps.Process, 0)
+	for _, p := range processes {
+		if id, err := syscall.Getpgid(p.Pid()); err == nil && id == pgid && processRunning(p.Pid()) {
+			group = append(group, p)
+		}
+	}
+	return group
+}
+
+func TestProcessTreeTerminate(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		sleeps int
+	}{
+		{"child and grandchild", "sleep 60 & sleep 60", 2},
+		// The subshell exits straight away, leaving its sleep to init
+		{"orphan", "(sleep 60 &); sleep 60", 2},
+	}
+
+	for _, tt := range tests {
+		tree := startTestProcessTree(t, tt.script, tt.sleeps)
+
+		if err := tree.Terminate(2 * time.Second); err != nil {
+			t.Errorf("%s: Terminate: %v", tt.name, err)
+		}
+
+		waitUntil(t, tt.name+" to be reaped", func() bool {
+			return len(groupProcesses(t, tree.Pid())) == 0
+		})
+	}
+}
+
+func TestTerminateProcessTreeKillsAfterGrace(t *testing.T) {
+	// Ignored signals stay ignored in the sleeps
+	tree := startTestProcessTree(t, "trap '' TERM; sleep 60 & sleep 60", 2)
+
+	const grace = 200 * time.Millisecond
+	start := time.Now()
+
+	if err := TerminateProcessTree(tree.Pid(), grace); err != nil {
+		t.Fatalf("TerminateProcessTree: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < grace {
+		t.Fatalf("killed after %v, before the %v grace period", elapsed, grace)
+	}
+
+	waitUntil(t, "the tree to be killed", func() bool {
+		return len(groupProcesses(t, tree.Pid())) == 0
+	})
+	tree.Wait()
+}
+
+func TestTerminateProcessesJoinsErrors(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root may signal every process")
+	}
+
+	// Processes of another user can't be signalled
+	pids := make([]int, 0)
+	processes, err := ps.Processes()
+	if err != nil {
+		t.Fatalf("listing processes: %v", err)
+	}
+	for _, p := range processes {
+		info, err := os.Stat("/proc/" + strconv.Itoa(p.Pid()))
+		if err != nil || !processRunning(p.Pid()) {
+			continue
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Geteuid() {
+			pids = append(pids, p.Pid())
+		}
+		if len(pids) == 2 {
+			break
+		}
+	}
+	if len(pids) < 2 {
+		t.Skip("no processes of other users to signal")
+	}
+
+	err = terminateProcesses(pids, 0)
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("terminateProcesses(%v) = %v, want an error for each", pids, err)
+	}
+}
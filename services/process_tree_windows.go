@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var postMessage = user32.NewProc("PostMessageW")
+
+const WM_CLOSE = 0x0010
+
+// processGroup is the job object a ProcessTree was started in. Processes
+// started from a job stay in it.
+type processGroup struct {
+	job windows.Handle
+}
+
+// prepareProcessGroup makes cmd start suspended, so it can't start any
+// processes of its own before newProcessGroup has put it in the job
+func prepareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_SUSPENDED
+}
+
+// newProcessGroup puts pid in a new job object and resumes it. The job
+// kills everything in it if the overlay exits without terminating it.
+func newProcessGroup(pid int) (*processGroup, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating job object: %w", err)
+	}
+
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	_, err = windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("error configuring job object: %w", err)
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(process)
+
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("error assigning process to job object: %w", err)
+	}
+
+	if err := resumeProcess(pid); err != nil {
+		windows.CloseHandle(job)
+		return nil, err
+	}
+
+	return &processGroup{job: job}, nil
+}
+
+// resumeProcess resumes the main thread of a process started suspended.
+// exec.Cmd closes the thread handle, so the thread is found by its owner.
+func resumeProcess(pid int) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return fmt.Errorf("error listing threads: %w", err)
+	}
+	defer windows.CloseHandle(snapshot)
+
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != uint32(pid) {
+			continue
+		}
+
+		thread, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			return fmt.Errorf("failed to open thread: %w", err)
+		}
+		_, err = windows.ResumeThread(thread)
+		windows.CloseHandle(thread)
+		if err != nil {
+			return fmt.Errorf("error resuming process: %w", err)
+		}
+
+		// A suspended process only has its main thread
+		return nil
+	}
+
+	return fmt.Errorf("no thread found for process %d", pid)
+}
+
+// kill terminates every process left in the job and closes it
+func (g *processGroup) kill() error {
+	if g.job == 0 {
+		return nil
+	}
+	err := windows.TerminateJobObject(g.job, 1)
+	windows.CloseHandle(g.job)
+	g.job = 0
+	return err
+}
+
+// askToExit posts WM_CLOSE to the windows of each process. Processes
+// without windows are left for killProcess.
+func askToExit(pids []int) error {
+	windowList, err := listWindows()
+	if err != nil {
+		return err
+	}
+
+	stopping := make(map[uint32]bool, len(pids))
+	for _, pid := range pids {
+		stopping[uint32(pid)] = true
+	}
+
+	for _, w := range windowList {
+		if stopping[w.PID] {
+			postMessage.Call(uintptr(w.Handle), WM_CLOSE, 0, 0)
+		}
+	}
+
+	return nil
+}
+
+// killProcess terminates a process
+func killProcess(pid int) error {
+	processHandle, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(processHandle)
+
+	if err := windows.TerminateProcess(processHandle, 1); err != nil {
+		return fmt.Errorf("failed to terminate process: %w", err)
+	}
+
+	return nil
+}
+
+// processRunning reports whether a process has yet to exit
+func processRunning(pid int) bool {
+	process, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		// Access is denied for processes that exist but belong to others
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(process)
+
+	event, err := windows.WaitForSingleObject(process, 0)
+	return err == nil && event == uint32(windows.WAIT_TIMEOUT)
+}
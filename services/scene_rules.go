@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Where the event of a SceneRule comes from
+const (
+	SceneSourceSocket = "socket" // Event is a Smash Soda socket event, such as "host:afk"
+	SceneSourceHotkey = "hotkey" // Event is a hotkey, such as "Ctrl+Alt+B"
+)
+
+const (
+	// SceneCommand is a socket event that switches scene straight away. Its
+	// data is the scene's ID or name, or {scene}.
+	SceneCommand = "scene:set"
+	// ScenePrevious as the scene of a rule goes back to the scene that was
+	// active before the current one
+	ScenePrevious = "previous"
+	// EventSceneHotkey is emitted when a rule's hotkey is pressed
+	EventSceneHotkey = "scene:hotkey"
+)
+
+const defaultSceneDebounce = time.Second
+
+// SceneRule switches scene when an event happens, such as going to "Be
+// Right Back" when Smash Soda reports the host is away
+type SceneRule struct {
+	Source string `json:"source"`
+	Event  string `json:"event"`
+	Field  string `json:"field,omitempty"` // Dotted path into the event's data, such as "state"
+	Value  string `json:"value,omitempty"` // Matches when Field has this value, ignoring case
+	Scene  string `json:"scene"`           // ID of the scene, or ScenePrevious
+}
+
+// SceneRules decides which scene to show. Rules are checked in order and
+// the first match wins. Socket events only switch scene once they have
+// stopped changing it for DebounceMs, so a flapping state doesn't flicker
+// the output; hotkeys switch straight away.
+type SceneRules struct {
+	Enabled    bool        `json:"enabled"`
+	Rules      []SceneRule `json:"rules"`
+	DebounceMs int         `json:"debounceMs"`
+}
+
+// GetSceneRules returns the rules that switch scene
+func (ss *SceneService) GetSceneRules() SceneRules {
+	return ss.GetScenes().Rules
+}
+
+// SetSceneRules replaces the rules that switch scene and registers their
+// hotkeys. The rules are saved even if a hotkey can't be registered.
+func (ss *SceneService) SetSceneRules(rules SceneRules) error {
+	if rules.Rules == nil {
+		rules.Rules = []SceneRule{}
+	}
+
+	ss.mu.Lock()
+	if err := checkSceneRules(rules, ss.state); err != nil {
+		ss.mu.Unlock()
+		return err
+	}
+
+	previous := ss.state.Rules
+	ss.state.Rules = rules
+	if err := ss.saveLocked(); err != nil {
+		ss.state.Rules = previous
+		ss.mu.Unlock()
+		return err
+	}
+	ss.cancelPendingLocked()
+	state := ss.state.clone()
+	ss.mu.Unlock()
+
+	emitAppEvent(EventScenesChanged, state)
+
+	return ss.registerRuleHotkeys(rules)
+}
+
+// checkSceneRules makes sure every rule can match and points at a scene
+func checkSceneRules(rules SceneRules, state SceneState) error {
+	if rules.DebounceMs < 0 {
+		return fmt.Errorf("scene debounce can't be negative")
+	}
+
+	for i, rule := range rules.Rules {
+		switch rule.Source {
+		case SceneSourceSocket:
+		case SceneSourceHotkey:
+			if _, err := ParseHotkeySequence(rule.Event); err != nil {
+				return fmt.Errorf("scene rule %d: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("scene rule %d: unknown source %q", i+1, rule.Source)
+		}
+
+		if strings.TrimSpace(rule.Event) == "" {
+			return fmt.Errorf("scene rule %d has no event", i+1)
+		}
+		if rule.Scene != ScenePrevious && state.find(rule.Scene) < 0 {
+			return fmt.Errorf("scene rule %d: scene %s not found", i+1, rule.Scene)
+		}
+	}
+
+	return nil
+}
+
+// registerRuleHotkeys replaces the hotkeys of the previous rules with
+// those of the given ones. It must not be called with ss.mu held, as a
+// hotkey that is firing takes it.
+func (ss *SceneService) registerRuleHotkeys(rules SceneRules) error {
+	if ss.hotkeys == nil {
+		return nil
+	}
+
+	ss.ruleKeysMu.Lock()
+	defer ss.ruleKeysMu.Unlock()
+
+	for _, name := range ss.ruleKeys {
+		ss.hotkeys.UnregisterHotkey(name)
+	}
+	ss.ruleKeys = nil
+
+	if !rules.Enabled {
+		return nil
+	}
+
+	var errs []error
+	for i, rule := range rules.Rules {
+		if rule.Source != SceneSourceHotkey {
+			continue
+		}
+
+		name := "scene-rule:" + strconv.Itoa(i)
+		err := ss.hotkeys.RegisterHotkey(RegisterHotkeyArgs{
+			Name:   name,
+			Hotkey: rule.Event,
+			Event:  EventSceneHotkey,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ss.ruleKeys = append(ss.ruleKeys, name)
+	}
+
+	return errors.Join(errs...)
+}
+
+// hotkeyFired switches to the scene of the rule whose hotkey was pressed
+func (ss *SceneService) hotkeyFired(name string, data HotkeyEventData) {
+	index, ok := strings.CutPrefix(name, "scene-rule:")
+	if !ok || data.Phase == HotkeyPhaseUp || data.Phase == HotkeyPhaseHoldEnd {
+		return
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return
+	}
+
+	ss.mu.Lock()
+	rules := ss.state.Rules
+	if !rules.Enabled || i >= len(rules.Rules) || rules.Rules[i].Source != SceneSourceHotkey {
+		ss.mu.Unlock()
+		return
+	}
+	ss.cancelPendingLocked()
+	scene := ss.resolveLocked(rules.Rules[i].Scene)
+	ss.mu.Unlock()
+
+	ss.switchScene(scene, "hotkey "+rules.Rules[i].Event)
+}
+
+// socketEvent handles a message from Smash Soda. Scene commands switch
+// straight away; rules are debounced.
+func (ss *SceneService) socketEvent(event string, data interface{}) {
+	if event == SceneCommand {
+		ss.sceneCommand(data)
+		return
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	rules := ss.state.Rules
+	if !rules.Enabled {
+		return
+	}
+
+	for _, rule := range rules.Rules {
+		if rule.Source != SceneSourceSocket || !rule.matches(event, data) {
+			continue
+		}
+
+		ss.cancelPendingLocked()
+
+		scene := ss.resolveLocked(rule.Scene)
+		if scene == ss.state.Active {
+			return
+		}
+
+		delay := defaultSceneDebounce
+		if rules.DebounceMs > 0 {
+			delay = time.Duration(rules.DebounceMs) * time.Millisecond
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(delay, func() {
+			ss.mu.Lock()
+			defer ss.mu.Unlock()
+
+			// Stop doesn't help once the callback has started, so make sure
+			// it wasn't cancelled while waiting for the lock
+			if ss.pending != timer || !ss.state.Rules.Enabled {
+				return
+			}
+			ss.pending = nil
+
+			if err := ss.setActiveSceneLocked(scene); err != nil {
+				fmt.Printf("Error switching scene for socket event %s: %v\n", event, err)
+			}
+		})
+		ss.pending = timer
+		return
+	}
+}
+
+// sceneCommand switches to the scene named by a scene:set command
+func (ss *SceneService) sceneCommand(data interface{}) {
+	if m, ok := data.(map[string]interface{}); ok {
+		data = m["scene"]
+	}
+	ref, _ := data.(string)
+
+	ss.mu.Lock()
+	ss.cancelPendingLocked()
+	scene := ""
+	for _, s := range ss.state.Scenes {
+		if s.ID == ref || strings.EqualFold(s.Name, ref) {
+			scene = s.ID
+			break
+		}
+	}
+	if ref == ScenePrevious {
+		scene = ss.resolveLocked(ref)
+	}
+	ss.mu.Unlock()
+
+	if scene == "" {
+		fmt.Printf("Scene command: scene %v not found\n", data)
+		return
+	}
+
+	ss.switchScene(scene, "scene command")
+}
+
+// switchScene makes a scene active on behalf of a rule or command
+func (ss *SceneService) switchScene(scene, reason string) {
+	if scene == "" {
+		return
+	}
+
+	if err := ss.SetActiveScene(scene); err != nil {
+		fmt.Printf("Error switching scene for %s: %v\n", reason, err)
+	}
+}
+
+// resolveLocked turns ScenePrevious into the scene it stands for. ss.mu
+// must be held.
+func (ss *SceneService) resolveLocked(scene string) string {
+	if scene == ScenePrevious {
+		return ss.previous
+	}
+	return scene
+}
+
+// cancelPendingLocked stops a debounced switch. ss.mu must be held.
+func (ss *SceneService) cancelPendingLocked() {
+	if ss.pending != nil {
+		ss.pending.Stop()
+		ss.pending = nil
+	}
+}
+
+func (r SceneRule) matches(event string, data interface{}) bool {
+	if r.Event != event {
+		return false
+	}
+	if r.Field == "" {
+		return true
+	}
+
+	value, ok := lookupField(data, r.Field)
+	return ok && strings.EqualFold(fmt.Sprint(value), r.Value)
+}
+
+// lookupField follows a dotted path, such as "host.state", into decoded
+// JSON
+func lookupField(data interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if data, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return data, true
+}
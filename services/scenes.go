@@ -0,0 +1,368 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EventScenesChanged is emitted with a SceneState whenever a scene is
+	// added, changed, removed or made active
+	EventScenesChanged = "scenes:changed"
+	// EventSceneActive is emitted with the Scene that has been made active
+	EventSceneActive = "scene:active"
+)
+
+// Scene is a layout of widgets for the headless output, such as the game or
+// a "Be Right Back" screen. Widgets are stored as the studio saves them.
+type Scene struct {
+	ID      string                   `json:"id"`
+	Name    string                   `json:"name"`
+	Widgets []map[string]interface{} `json:"widgets"`
+}
+
+// SceneState is every scene, the ID of the active one and the rules that
+// switch between them
+type SceneState struct {
+	Scenes []Scene    `json:"scenes"`
+	Active string     `json:"active"`
+	Rules  SceneRules `json:"rules"`
+}
+
+// SceneService keeps the scenes shown by the headless window. Scenes are
+// saved to scenes.json in the user's config folder after every change.
+type SceneService struct {
+	path     string
+	state    SceneState
+	previous string      // The scene that was active before the current one
+	pending  *time.Timer // A debounced switch made by a rule
+	mu       sync.Mutex
+
+	hotkeys    *HotkeyService
+	ruleKeys   []string   // Names of the hotkeys registered for rules
+	ruleKeysMu sync.Mutex // Held while registering, never with mu
+}
+
+// NewSceneService creates a new SceneService and loads the saved scenes.
+// Its rules follow the messages from Smash Soda received by sources and
+// register their hotkeys with hotkeys.
+func NewSceneService(hotkeys *HotkeyService, sources ...socketSource) *SceneService {
+	path := "scenes.json"
+	if dir, err := GetConfigDir(); err == nil {
+		path = filepath.Join(dir, path)
+	} else {
+		fmt.Println("Error getting config directory:", err)
+	}
+
+	ss := newSceneService(path)
+	ss.hotkeys = hotkeys
+
+	hotkeys.onHotkey(ss.hotkeyFired)
+	for _, source := range sources {
+		source.handleSocketMessages(ss.socketEvent)
+	}
+
+	if err := ss.registerRuleHotkeys(ss.GetSceneRules()); err != nil {
+		fmt.Println("Error registering scene hotkeys:", err)
+	}
+
+	return ss
+}
+
+// newSceneService creates a SceneService that saves to the given file, so
+// that tests can use a temporary one
+func newSceneService(path string) *SceneService {
+	ss := &SceneService{path: path}
+
+	state, err := loadScenes(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Println("Error loading scenes, using the defaults:", err)
+		}
+		state = defaultScenes()
+	}
+	ss.state = state
+
+	return ss
+}
+
+// defaultScenes are the scenes a new install starts with
+func defaultScenes() SceneState {
+	return SceneState{
+		Scenes: []Scene{
+			{ID: "game", Name: "Game", Widgets: []map[string]interface{}{}},
+			{ID: "brb", Name: "Be Right Back", Widgets: []map[string]interface{}{}},
+			{ID: "coming-soon", Name: "Coming Soon", Widgets: []map[string]interface{}{}},
+		},
+		Active: "game",
+	}
+}
+
+func loadScenes(path string) (SceneState, error) {
+	var state SceneState
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(state.Scenes) == 0 {
+		return state, fmt.Errorf("%s has no scenes", path)
+	}
+
+	for i := range state.Scenes {
+		if state.Scenes[i].Widgets == nil {
+			state.Scenes[i].Widgets = []map[string]interface{}{}
+		}
+	}
+	if state.find(state.Active) < 0 {
+		state.Active = state.Scenes[0].ID
+	}
+	if err := checkSceneRules(state.Rules, state); err != nil {
+		fmt.Println("Error loading scene rules, turning them off:", err)
+		state.Rules = SceneRules{}
+	}
+
+	return state, nil
+}
+
+// GetScenes returns every scene and which one is active
+func (ss *SceneService) GetScenes() SceneState {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.state.clone()
+}
+
+// GetActiveScene returns the scene the headless window is showing
+func (ss *SceneService) GetActiveScene() Scene {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.state.clone().Scenes[ss.state.find(ss.state.Active)]
+}
+
+// SetActiveScene switches the headless window to another scene
+func (ss *SceneService) SetActiveScene(id string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.setActiveSceneLocked(id)
+}
+
+// setActiveSceneLocked makes a scene active. ss.mu must be held.
+func (ss *SceneService) setActiveSceneLocked(id string) error {
+	i := ss.state.find(id)
+	if i < 0 {
+		return fmt.Errorf("scene %s not found", id)
+	}
+	if ss.state.Active == id {
+		return nil
+	}
+
+	previous := ss.state.Active
+	ss.state.Active = id
+	if err := ss.saveLocked(); err != nil {
+		ss.state.Active = previous
+		return err
+	}
+	ss.previous = previous
+
+	fmt.Printf("Switched to scene %s\n", ss.state.Scenes[i].Name)
+
+	state := ss.state.clone()
+	emitAppEvent(EventSceneActive, state.Scenes[i])
+	emitAppEvent(EventScenesChanged, state)
+
+	return nil
+}
+
+// CreateScene adds an empty scene with the given name
+func (ss *SceneService) CreateScene(name string) (Scene, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Scene{}, fmt.Errorf("scene has no name")
+	}
+
+	id, err := newSceneID()
+	if err != nil {
+		return Scene{}, err
+	}
+	scene := Scene{ID: id, Name: name, Widgets: []map[string]interface{}{}}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.state.Scenes = append(ss.state.Scenes, scene)
+	if err := ss.saveLocked(); err != nil {
+		ss.state.Scenes = ss.state.Scenes[:len(ss.state.Scenes)-1]
+		return Scene{}, err
+	}
+
+	emitAppEvent(EventScenesChanged, ss.state.clone())
+
+	return scene, nil
+}
+
+// UpdateScene replaces the name and widgets of the scene with the same ID
+func (ss *SceneService) UpdateScene(scene Scene) (Scene, error) {
+	scene.Name = strings.TrimSpace(scene.Name)
+	if scene.Name == "" {
+		return Scene{}, fmt.Errorf("scene has no name")
+	}
+	if scene.Widgets == nil {
+		scene.Widgets = []map[string]interface{}{}
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	i := ss.state.find(scene.ID)
+	if i < 0 {
+		return Scene{}, fmt.Errorf("scene %s not found", scene.ID)
+	}
+
+	previous := ss.state.Scenes[i]
+	ss.state.Scenes[i] = scene
+	if err := ss.saveLocked(); err != nil {
+		ss.state.Scenes[i] = previous
+		return Scene{}, err
+	}
+
+	state := ss.state.clone()
+	if scene.ID == ss.state.Active {
+		emitAppEvent(EventSceneActive, state.Scenes[i])
+	}
+	emitAppEvent(EventScenesChanged, state)
+
+	return state.Scenes[i], nil
+}
+
+// DeleteScene removes a scene and the rules that switch to it. The last
+// scene can't be removed. If the active scene is removed, the first one is
+// made active.
+func (ss *SceneService) DeleteScene(id string) error {
+	ss.mu.Lock()
+
+	i := ss.state.find(id)
+	if i < 0 {
+		ss.mu.Unlock()
+		return fmt.Errorf("scene %s not found", id)
+	}
+	if len(ss.state.Scenes) == 1 {
+		ss.mu.Unlock()
+		return fmt.Errorf("the last scene can't be deleted")
+	}
+
+	previous := ss.state.clone()
+	ss.state.Scenes = append(ss.state.Scenes[:i], ss.state.Scenes[i+1:]...)
+
+	activeChanged := ss.state.Active == id
+	if activeChanged {
+		ss.state.Active = ss.state.Scenes[0].ID
+	}
+
+	rules := []SceneRule{}
+	for _, rule := range ss.state.Rules.Rules {
+		if rule.Scene != id {
+			rules = append(rules, rule)
+		}
+	}
+	rulesChanged := len(rules) != len(ss.state.Rules.Rules)
+	ss.state.Rules.Rules = rules
+
+	if err := ss.saveLocked(); err != nil {
+		ss.state = previous
+		ss.mu.Unlock()
+		return err
+	}
+	if ss.previous == id {
+		ss.previous = ""
+	}
+
+	state := ss.state.clone()
+	ss.mu.Unlock()
+
+	if activeChanged {
+		emitAppEvent(EventSceneActive, state.Scenes[0])
+	}
+	emitAppEvent(EventScenesChanged, state)
+
+	if rulesChanged {
+		if err := ss.registerRuleHotkeys(state.Rules); err != nil {
+			fmt.Println("Error registering scene hotkeys:", err)
+		}
+	}
+
+	return nil
+}
+
+// saveLocked writes the scenes to a temporary file and moves it over the
+// old one, so a crash never leaves half a file. ss.mu must be held.
+func (ss *SceneService) saveLocked() error {
+	data, err := json.MarshalIndent(ss.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding scenes: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ss.path), os.ModePerm); err != nil {
+		return fmt.Errorf("error saving scenes: %w", err)
+	}
+
+	tmp := ss.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error saving scenes: %w", err)
+	}
+	if err := os.Rename(tmp, ss.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error saving scenes: %w", err)
+	}
+
+	return nil
+}
+
+// find returns the index of the scene with the given ID, or -1
+func (s SceneState) find(id string) int {
+	for i, scene := range s.Scenes {
+		if scene.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone deep copies the state, so that callers and events never share the
+// widgets with the service
+func (s SceneState) clone() SceneState {
+	var out SceneState
+
+	data, err := json.Marshal(s)
+	if err == nil {
+		err = json.Unmarshal(data, &out)
+	}
+	if err != nil {
+		fmt.Println("Error copying scenes:", err)
+		return s
+	}
+
+	return out
+}
+
+func newSceneID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error creating scene ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
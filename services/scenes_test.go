@@ -0,0 +1,194 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestSceneService wires a SceneService to a fake hotkey backend the way
+// NewSceneService wires it to the OS
+func newTestSceneService(t *testing.T) (*SceneService, *fakeHotkeyBackend) {
+	t.Helper()
+
+	h, backend, _ := newTestHotkeyService()
+	t.Cleanup(h.UnregisterAll)
+
+	ss := newSceneService(filepath.Join(t.TempDir(), "scenes.json"))
+	ss.hotkeys = h
+	h.onHotkey(ss.hotkeyFired)
+
+	return ss, backend
+}
+
+func activeScene(ss *SceneService) string {
+	return ss.GetScenes().Active
+}
+
+func TestSceneRuleHotkeys(t *testing.T) {
+	ss, backend := newTestSceneService(t)
+
+	err := ss.SetSceneRules(SceneRules{
+		Enabled: true,
+		Rules: []SceneRule{
+			{Source: SceneSourceHotkey, Event: "Ctrl+Alt+B", Scene: "brb"},
+			{Source: SceneSourceHotkey, Event: "Ctrl+Alt+G", Scene: ScenePrevious},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetSceneRules: %v", err)
+	}
+	assertRegistered(t, backend, 2)
+
+	backend.Press("Ctrl+Alt+B")
+	waitUntil(t, "the brb scene", func() bool { return activeScene(ss) == "brb" })
+
+	backend.Press("Ctrl+Alt+G")
+	waitUntil(t, "the previous scene", func() bool { return activeScene(ss) == "game" })
+
+	// Deleting a scene drops the rules that switch to it
+	if err := ss.DeleteScene("brb"); err != nil {
+		t.Fatalf("DeleteScene: %v", err)
+	}
+	assertRegistered(t, backend, 1)
+	if backend.IsRegistered("Ctrl+Alt+B") {
+		t.Fatal("the hotkey of the deleted scene's rule is still registered")
+	}
+
+	if err := ss.SetSceneRules(SceneRules{Enabled: false, Rules: ss.GetSceneRules().Rules}); err != nil {
+		t.Fatalf("SetSceneRules: %v", err)
+	}
+	assertRegistered(t, backend, 0)
+}
+
+func TestSceneRulesInvalid(t *testing.T) {
+	ss, backend := newTestSceneService(t)
+
+	for _, rules := range []SceneRules{
+		{Enabled: true, DebounceMs: -1},
+		{Enabled: true, Rules: []SceneRule{{Source: "timer", Event: "tick", Scene: "brb"}}},
+		{Enabled: true, Rules: []SceneRule{{Source: SceneSourceSocket, Event: " ", Scene: "brb"}}},
+		{Enabled: true, Rules: []SceneRule{{Source: SceneSourceSocket, Event: "host:afk", Scene: "missing"}}},
+		{Enabled: true, Rules: []SceneRule{{Source: SceneSourceHotkey, Event: "Ctrl+Nope", Scene: "brb"}}},
+	} {
+		if err := ss.SetSceneRules(rules); err == nil {
+			t.Errorf("SetSceneRules(%+v) succeeded", rules)
+		}
+	}
+
+	assertRegistered(t, backend, 0)
+}
+
+func TestSceneRuleSocketEventsDebounce(t *testing.T) {
+	ss, _ := newTestSceneService(t)
+
+	err := ss.SetSceneRules(SceneRules{
+		Enabled:    true,
+		DebounceMs: 50,
+		Rules: []SceneRule{
+			{Source: SceneSourceSocket, Event: "host:afk", Field: "state", Value: "away", Scene: "brb"},
+			{Source: SceneSourceSocket, Event: "host:afk", Field: "state", Value: "back", Scene: "game"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetSceneRules: %v", err)
+	}
+
+	// A state that flips straight back never switches scene
+	ss.socketEvent("host:afk", map[string]interface{}{"state": "away"})
+	ss.socketEvent("host:afk", map[string]interface{}{"state": "back"})
+	time.Sleep(100 * time.Millisecond)
+	if scene := activeScene(ss); scene != "game" {
+		t.Fatalf("active scene after flapping = %s, want game", scene)
+	}
+
+	ss.socketEvent("host:afk", map[string]interface{}{"state": "AWAY"})
+	if scene := activeScene(ss); scene != "game" {
+		t.Fatal("the switch wasn't debounced")
+	}
+	waitUntil(t, "the brb scene", func() bool { return activeScene(ss) == "brb" })
+}
+
+func TestSceneRuleDebounceCancelledInFlight(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel func(ss *SceneService) // Called with ss.mu held
+		want   string
+	}{
+		{"not cancelled", func(ss *SceneService) {}, "brb"},
+		{"newer command", (*SceneService).cancelPendingLocked, "game"},
+		{"rules turned off", func(ss *SceneService) { ss.state.Rules.Enabled = false }, "game"},
+	}
+
+	for _, tt := range tests {
+		ss, _ := newTestSceneService(t)
+
+		err := ss.SetSceneRules(SceneRules{
+			Enabled:    true,
+			DebounceMs: 20,
+			Rules: []SceneRule{
+				{Source: SceneSourceSocket, Event: "host:afk", Field: "state", Value: "away", Scene: "brb"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("SetSceneRules: %v", err)
+		}
+
+		// Hold the lock until the debounced switch has started and is
+		// waiting for it
+		ss.socketEvent("host:afk", map[string]interface{}{"state": "away"})
+		ss.mu.Lock()
+		time.Sleep(60 * time.Millisecond)
+		tt.cancel(ss)
+		ss.mu.Unlock()
+
+		time.Sleep(30 * time.Millisecond)
+		if scene := activeScene(ss); scene != tt.want {
+			t.Errorf("%s: active scene = %s, want %s", tt.name, scene, tt.want)
+		}
+	}
+}
+
+func TestSceneCommand(t *testing.T) {
+	ss, _ := newTestSceneService(t)
+
+	// Commands work without any rules
+	ss.socketEvent(SceneCommand, "Be Right Back")
+	if scene := activeScene(ss); scene != "brb" {
+		t.Fatalf("active scene = %s, want brb", scene)
+	}
+
+	ss.socketEvent(SceneCommand, map[string]interface{}{"scene": "coming-soon"})
+	if scene := activeScene(ss); scene != "coming-soon" {
+		t.Fatalf("active scene = %s, want coming-soon", scene)
+	}
+
+	ss.socketEvent(SceneCommand, ScenePrevious)
+	if scene := activeScene(ss); scene != "brb" {
+		t.Fatalf("active scene = %s, want brb", scene)
+	}
+
+	ss.socketEvent(SceneCommand, "missing")
+	if scene := activeScene(ss); scene != "brb" {
+		t.Fatalf("an unknown scene switched to %s", scene)
+	}
+}
+
+func TestScenesAreSaved(t *testing.T) {
+	// The config folder doesn't exist until the first save
+	path := filepath.Join(t.TempDir(), "Smash Glass", "scenes.json")
+
+	ss := newSceneService(path)
+	scene, err := ss.CreateScene("Intermission")
+	if err != nil {
+		t.Fatalf("CreateScene: %v", err)
+	}
+	if err := ss.SetActiveScene(scene.ID); err != nil {
+		t.Fatalf("SetActiveScene: %v", err)
+	}
+
+	loaded := newSceneService(path).GetScenes()
+	if loaded.Active != scene.ID || len(loaded.Scenes) != 4 {
+		t.Fatalf("loaded %d scenes with %s active, want 4 with %s", len(loaded.Scenes), loaded.Active, scene.ID)
+	}
+}
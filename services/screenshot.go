@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EventScreenshotSaved is emitted with a Screenshot once one has been saved
+const EventScreenshotSaved = "screenshot:saved"
+
+// maxGameNameLength keeps screenshot file names readable
+const maxGameNameLength = 60
+
+// reservedFileNames are the device names Windows doesn't allow as files
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Screenshot describes a saved screenshot
+type Screenshot struct {
+	Path    string `json:"path"`
+	Monitor string `json:"monitor"` // Monitor.ID of the monitor captured
+	Game    string `json:"game"`    // Title of the foreground window, if it isn't ours
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// SetScreenshotFolder sets where screenshots are saved. An empty folder
+// uses "Smash Glass" in the user's Pictures folder.
+func (ws *WindowService) SetScreenshotFolder(folder string) {
+	ws.screenshotMu.Lock()
+	defer ws.screenshotMu.Unlock()
+
+	ws.screenshotFolder = strings.TrimSpace(folder)
+}
+
+// GetScreenshotFolder returns where screenshots are saved
+func (ws *WindowService) GetScreenshotFolder() (string, error) {
+	ws.screenshotMu.Lock()
+	defer ws.screenshotMu.Unlock()
+
+	return ws.screenshotFolderLocked()
+}
+
+func (ws *WindowService) screenshotFolderLocked() (string, error) {
+	if ws.screenshotFolder != "" {
+		return ws.screenshotFolder, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %w", err)
+	}
+	return filepath.Join(home, "Pictures", "Smash Glass"), nil
+}
+
+// CaptureScreenshot saves a full resolution PNG of a monitor to the
+// screenshot folder and emits screenshot:saved. An empty monitor ID is the
+// one the overlay is on. The overlays are left out unless includeOverlay
+// is set.
+func (ws *WindowService) CaptureScreenshot(monitor string, includeOverlay bool) (Screenshot, error) {
+	ws.screenshotMu.Lock()
+	defer ws.screenshotMu.Unlock()
+
+	m, err := ws.screenshotMonitor(monitor)
+	if err != nil {
+		return Screenshot{}, err
+	}
+
+	folder, err := ws.screenshotFolderLocked()
+	if err != nil {
+		return Screenshot{}, err
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return Screenshot{}, fmt.Errorf("error creating screenshot folder: %w", err)
+	}
+
+	// Name the screenshot after the game before the capture can change
+	// the focus
+	game := ""
+	if info, err := foregroundWindow(); err == nil && info.PID != uint32(os.Getpid()) {
+		game = info.Title
+		if game == "" {
+			game = strings.TrimSuffix(info.Process, filepath.Ext(info.Process))
+		}
+	}
+
+	restore := func() {}
+	if !includeOverlay {
+		restore = excludeFromCapture(ws.overlayWindows())
+	}
+	img, err := ws.captureScreen(m.X, m.Y, m.Width, m.Height, m.Width, m.Height)
+	restore()
+	if err != nil {
+		return Screenshot{}, fmt.Errorf("error capturing monitor %s: %w", m.ID, err)
+	}
+
+	file, path, err := createScreenshotFile(folder, screenshotName(time.Now(), game))
+	if err != nil {
+		return Screenshot{}, err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		os.Remove(path)
+		return Screenshot{}, fmt.Errorf("error encoding screenshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return Screenshot{}, fmt.Errorf("error saving screenshot: %w", err)
+	}
+
+	fmt.Printf("Saved screenshot of %s to %s\n", m.Name, path)
+
+	shot := Screenshot{
+		Path:    path,
+		Monitor: m.ID,
+		Game:    game,
+		Width:   m.Width,
+		Height:  m.Height,
+	}
+	emitAppEvent(EventScreenshotSaved, shot)
+
+	return shot, nil
+}
+
+// screenshotMonitor finds the monitor to capture. A monitor asked for by ID
+// must be connected, but the overlay's own monitor falls back to the
+// closest match once it is unplugged, as the overlay does.
+func (ws *WindowService) screenshotMonitor(id string) (Monitor, error) {
+	resolve := id
+	if resolve == "" {
+		resolve = ws.GetDisplay().ID
+	}
+
+	monitors := ws.listMonitors()
+	index := resolveMonitor(monitors, resolve)
+	if index < 0 {
+		return Monitor{}, fmt.Errorf("no monitors found")
+	}
+	if id != "" && monitors[index].ID != id {
+		return Monitor{}, fmt.Errorf("monitor %s not found", id)
+	}
+
+	return monitors[index], nil
+}
+
+// screenshotName is the file name of a screenshot, without its extension,
+// such as "2024-05-01_20-15-09_Street Fighter 6"
+func screenshotName(taken time.Time, game string) string {
+	name := taken.Format("2006-01-02_15-04-05")
+	if game = sanitizeFileName(game); game != "" {
+		name += "_" + game
+	}
+	return name
+}
+
+// sanitizeFileName removes the characters file systems don't allow,
+// collapses spaces and shortens the name
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+
+	if runes := []rune(name); len(runes) > maxGameNameLength {
+		name = string(runes[:maxGameNameLength])
+	}
+
+	// Windows doesn't allow names ending in a dot or space
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+
+	// Nor device names, even with an extension
+	base, _, _ := strings.Cut(name, ".")
+	if reservedFileNames[strings.ToUpper(strings.TrimSpace(base))] {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// createScreenshotFile creates a new PNG file, numbering it if several
+// screenshots are taken within a second
+func createScreenshotFile(folder, name string) (*os.File, string, error) {
+	for i := 1; i < 100; i++ {
+		path := filepath.Join(folder, name+".png")
+		if i > 1 {
+			path = filepath.Join(folder, name+"-"+strconv.Itoa(i)+".png")
+		}
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return file, path, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, "", fmt.Errorf("error saving screenshot: %w", err)
+		}
+	}
+
+	return nil, "", fmt.Errorf("error saving screenshot: too many named %s", name)
+}
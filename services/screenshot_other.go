@@ -0,0 +1,30 @@
+//go:build !windows
+
+package services
+
+import (
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// captureSettle is how long the screen is given to redraw without the
+// hidden windows
+const captureSettle = 150 * time.Millisecond
+
+// excludeFromCapture hides windows until restore is called, as screen
+// captures can't leave out a window that is shown
+func excludeFromCapture(windows []*application.WebviewWindow) (restore func()) {
+	for _, w := range windows {
+		w.Hide()
+	}
+	if len(windows) > 0 {
+		time.Sleep(captureSettle)
+	}
+
+	return func() {
+		for _, w := range windows {
+			w.Show()
+		}
+	}
+}
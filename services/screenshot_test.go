@@ -0,0 +1,96 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScreenshotMonitor(t *testing.T) {
+	ws := newWindowService(WindowModeOverlay, newFakeMonitorSource(testMonitors...))
+
+	tests := []struct {
+		overlay string // The monitor the overlay was placed on
+		id      string
+		want    string // Empty if screenshotMonitor must fail
+	}{
+		{"DP-2/GSM5B08", "", "DP-2/GSM5B08"},
+		{"DP-2/GSM5B08", "HDMI-1/SAM0F99", "HDMI-1/SAM0F99"},
+		// The overlay's monitor was unplugged, so the overlay moved to the
+		// primary one
+		{"DP-9/UNKNOWN", "", "DP-1/DEL40F0"},
+		{"DP-2/GSM5B08", "DP-9/UNKNOWN", ""},
+	}
+
+	for _, tt := range tests {
+		ws.display = DisplayInfo{ID: tt.overlay}
+
+		m, err := ws.screenshotMonitor(tt.id)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("screenshotMonitor(%q) = %s, want an error", tt.id, m.ID)
+			}
+			continue
+		}
+		if err != nil || m.ID != tt.want {
+			t.Errorf("screenshotMonitor(%q) with the overlay on %s = %s, %v, want %s", tt.id, tt.overlay, m.ID, err, tt.want)
+		}
+	}
+
+	ws = newWindowService(WindowModeOverlay, newFakeMonitorSource())
+	if _, err := ws.screenshotMonitor(""); err == nil {
+		t.Error("screenshotMonitor without monitors succeeded")
+	}
+}
+
+func TestScreenshotName(t *testing.T) {
+	taken := time.Date(2024, 5, 1, 20, 15, 9, 0, time.Local)
+
+	tests := []struct {
+		game string
+		want string
+	}{
+		{"Street Fighter 6", "2024-05-01_20-15-09_Street Fighter 6"},
+		{"", "2024-05-01_20-15-09"},
+		{"???", "2024-05-01_20-15-09"},
+		{"CON", "2024-05-01_20-15-09__CON"},
+	}
+
+	for _, tt := range tests {
+		if got := screenshotName(taken, tt.game); got != tt.want {
+			t.Errorf("screenshotName(%q) = %q, want %q", tt.game, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Street Fighter 6", "Street Fighter 6"},
+		{`a<b>c:d"e/f\g|h?i*j`, "abcdefghij"},
+		{"tab\there\x00", "tabhere"},
+		{"  lots   of \n space  ", "lots of space"},
+		{"Game...", "Game"},
+		{"Game . . ", "Game"},
+		{"CON", "_CON"},
+		{"nul.txt", "_nul.txt"},
+		{"com1", "_com1"},
+		{"COM10", "COM10"},
+		{"Console", "Console"},
+		{"", ""},
+		{"...", ""},
+		{"<>", ""},
+		{strings.Repeat("a", 100), strings.Repeat("a", maxGameNameLength)},
+		{strings.Repeat("é", 100), strings.Repeat("é", maxGameNameLength)},
+		// Shortening can leave a trailing dot
+		{strings.Repeat("a", maxGameNameLength-1) + ". more", strings.Repeat("a", maxGameNameLength-1)},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
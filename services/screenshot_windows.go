@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+var setWindowDisplayAffinity = user32.NewProc("SetWindowDisplayAffinity")
+
+const (
+	WDA_NONE               = 0x00
+	WDA_EXCLUDEFROMCAPTURE = 0x11
+)
+
+// captureSettle is how long the compositor is given to redraw the screen
+// without the excluded windows
+const captureSettle = 50 * time.Millisecond
+
+// excludeFromCapture keeps windows out of screen captures until restore is
+// called. The windows stay on screen; those that can't be excluded, before
+// Windows 10 2004, are hidden instead.
+func excludeFromCapture(windows []*application.WebviewWindow) (restore func()) {
+	var excluded []uintptr
+	var hidden []*application.WebviewWindow
+
+	for _, w := range windows {
+		hwnd, err := w.NativeWindowHandle()
+		if err == nil {
+			if ret, _, _ := setWindowDisplayAffinity.Call(hwnd, WDA_EXCLUDEFROMCAPTURE); ret != 0 {
+				excluded = append(excluded, hwnd)
+				continue
+			}
+		}
+
+		w.Hide()
+		hidden = append(hidden, w)
+	}
+
+	if len(excluded) > 0 || len(hidden) > 0 {
+		time.Sleep(captureSettle)
+	}
+
+	return func() {
+		for _, hwnd := range excluded {
+			if ret, _, err := setWindowDisplayAffinity.Call(hwnd, WDA_NONE); ret == 0 {
+				fmt.Println("Error restoring window display affinity:", err)
+			}
+		}
+		for _, w := range hidden {
+			w.Show()
+		}
+	}
+}
@@ -7,30 +7,63 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Theme struct {
+	ID        string
+	Parent    string
+	Meta      map[string]interface{}
+	Variables []ThemeVariable
+}
+
+// themeLayer is one theme in an inheritance chain
+type themeLayer struct {
 	ID   string
+	Dir  string
 	Meta map[string]interface{}
 }
 
 type StyleService struct {
-	rawBaseURL string
+	rawBaseURL   string
+	themesDir    string // Installed themes, one folder each
+	overridesDir string // The user's variable overrides, kept apart so updating a theme doesn't lose them
 }
 
 func NewStyleService() *StyleService {
+	themesDir := "themes"
+	if dir, err := GetExecutableDir(); err == nil {
+		themesDir = filepath.Join(dir, themesDir)
+	} else {
+		fmt.Println("Error getting executable directory:", err)
+	}
+
+	overridesDir := filepath.Join(themesDir, "..", "theme-overrides")
+	if dir, err := GetConfigDir(); err == nil {
+		overridesDir = filepath.Join(dir, "theme-overrides")
+	} else {
+		fmt.Println("Error getting config directory:", err)
+	}
+
+	return newStyleService(themesDir, overridesDir)
+}
+
+// newStyleService creates a StyleService that reads themes and overrides
+// from the given folders, so that tests can use temporary ones
+func newStyleService(themesDir, overridesDir string) *StyleService {
 	return &StyleService{
-		rawBaseURL: "https://raw.githubusercontent.com/trybuchet/smash-soda-registry/main/overlay/themes",
+		rawBaseURL:   "https://raw.githubusercontent.com/trybuchet/smash-soda-registry/main/overlay/themes",
+		themesDir:    filepath.Clean(themesDir),
+		overridesDir: filepath.Clean(overridesDir),
 	}
 }
 
 func (s *StyleService) DownloadTheme(themeID string, files []string) error {
-	execDir, err := GetExecutableDir()
+	themeDir, err := s.themeDir(themeID)
 	if err != nil {
 		return err
 	}
 
-	themeDir := filepath.Join(execDir, "themes", themeID)
 	if err := os.MkdirAll(themeDir, os.ModePerm); err != nil {
 		return err
 	}
@@ -54,13 +87,7 @@ func (s *StyleService) DownloadTheme(themeID string, files []string) error {
 func (s *StyleService) GetOverlayStyles() []Theme {
 	themes := make([]Theme, 0)
 
-	dir, err := GetExecutableDir()
-	if err != nil {
-		fmt.Println("Error getting executable directory:", err)
-		return themes
-	}
-
-	themesDir := filepath.Join(dir, "themes")
+	themesDir := s.themesDir
 	if _, err := os.Stat(themesDir); os.IsNotExist(err) {
 		fmt.Println("Themes directory does not exist:", themesDir)
 		return themes
@@ -80,28 +107,120 @@ func (s *StyleService) GetOverlayStyles() []Theme {
 		themeID := entry.Name()
 		themePath := filepath.Join(themesDir, themeID)
 
-		metaPath := filepath.Join(themePath, "meta.json")
-		metaContent, metaErr := os.ReadFile(metaPath)
-		if metaErr != nil || len(metaContent) == 0 {
-			metaContent = []byte("{}")
-		}
-
-		var meta map[string]interface{}
-		if err := json.Unmarshal(metaContent, &meta); err != nil {
+		meta, err := readThemeMeta(themePath)
+		if err != nil {
 			fmt.Println("Error parsing meta file for theme:", themeID, err)
 			continue
 		}
 
+		// Include the variables inherited from parent themes
+		variables := make([]ThemeVariable, 0)
+		if chain, err := s.resolveThemeChain(themeID); err != nil {
+			fmt.Println("Error resolving parents of theme:", themeID, err)
+		} else if variables, err = mergeThemeVariables(chain); err != nil {
+			fmt.Println("Error parsing variables for theme:", themeID, err)
+			variables = make([]ThemeVariable, 0)
+		}
+
 		themes = append(themes, Theme{
-			ID:   themeID,
-			Meta: meta,
+			ID:        themeID,
+			Parent:    themeParent(meta),
+			Meta:      meta,
+			Variables: variables,
 		})
 	}
 
 	return themes
 }
 
+// GetOverlayThemeCSS builds the stylesheet for a theme: the user's variable
+// values, followed by the CSS of each parent theme and then the theme itself
 func (s *StyleService) GetOverlayThemeCSS(themeID string) (string, error) {
+	chain, err := s.resolveThemeChain(themeID)
+	if err != nil {
+		return "", err
+	}
+
+	variables, err := s.GetThemeVariables(themeID)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(buildThemeVariablesCSS(variables))
+
+	for i, layer := range chain {
+		cssPath := filepath.Join(layer.Dir, layer.ID+".css")
+		cssContent, err := os.ReadFile(cssPath)
+		if err != nil {
+			// A child theme may only change variables of its parent
+			if os.IsNotExist(err) && i > 0 {
+				continue
+			}
+			return "", fmt.Errorf("error reading CSS of theme %s: %w", layer.ID, err)
+		}
+
+		// Widgets are positioned by the overlay, so drop any layout rules the
+		// theme sets on their containers
+		fmt.Fprintf(&sb, "/* Theme: %s */\n", layer.ID)
+		sb.WriteString(stripThemeLayoutRules(string(cssContent)))
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// resolveThemeChain follows the "parent" entries of theme manifests and
+// returns the chain from the root theme down to themeID
+func (s *StyleService) resolveThemeChain(themeID string) ([]themeLayer, error) {
+	chain := make([]themeLayer, 0)
+	visited := make([]string, 0)
+
+	id := themeID
+	child := ""
+	for id != "" {
+		for _, seen := range visited {
+			if seen == id {
+				return nil, fmt.Errorf("theme %s has an inheritance cycle: %s -> %s",
+					themeID, strings.Join(visited, " -> "), id)
+			}
+		}
+		visited = append(visited, id)
+
+		themeDir, err := s.themeDir(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if info, err := os.Stat(themeDir); err != nil || !info.IsDir() {
+			if child != "" {
+				return nil, fmt.Errorf("parent theme %s of %s is not installed", id, child)
+			}
+			return nil, fmt.Errorf("theme %s is not installed", id)
+		}
+
+		meta, err := readThemeMeta(themeDir)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %w", id, err)
+		}
+
+		chain = append([]themeLayer{{ID: id, Dir: themeDir, Meta: meta}}, chain...)
+
+		child = id
+		id = themeParent(meta)
+	}
+
+	return chain, nil
+}
+
+// themeParent returns the parent theme named in a manifest, if any
+func themeParent(meta map[string]interface{}) string {
+	parent, _ := meta["parent"].(string)
+	return strings.TrimSpace(parent)
+}
+
+// themeDir validates a theme ID and returns the path of its folder
+func (s *StyleService) themeDir(themeID string) (string, error) {
 	if themeID == "" {
 		return "", fmt.Errorf("theme ID is required")
 	}
@@ -111,18 +230,23 @@ func (s *StyleService) GetOverlayThemeCSS(themeID string) (string, error) {
 		return "", fmt.Errorf("invalid theme ID")
 	}
 
-	dir, err := GetExecutableDir()
-	if err != nil {
-		return "", fmt.Errorf("error getting executable directory: %w", err)
+	return filepath.Join(s.themesDir, cleanID), nil
+}
+
+// readThemeMeta reads the meta.json manifest of a theme. A missing or
+// empty manifest is treated as an empty object.
+func readThemeMeta(themeDir string) (map[string]interface{}, error) {
+	metaContent, err := os.ReadFile(filepath.Join(themeDir, "meta.json"))
+	if err != nil || len(metaContent) == 0 {
+		metaContent = []byte("{}")
 	}
 
-	cssPath := filepath.Join(dir, "themes", cleanID, cleanID+".css")
-	cssContent, err := os.ReadFile(cssPath)
-	if err != nil {
-		return "", fmt.Errorf("error reading theme CSS: %w", err)
+	var meta map[string]interface{}
+	if err := json.Unmarshal(metaContent, &meta); err != nil {
+		return nil, fmt.Errorf("error parsing theme meta: %w", err)
 	}
 
-	return string(cssContent), nil
+	return meta, nil
 }
 
 var errThemeNotFound = fmt.Errorf("not found")
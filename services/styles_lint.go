@@ -0,0 +1,536 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ThemeLintIssue describes a rule in a theme that fights with the overlay's
+// own widget layout.
+type ThemeLintIssue struct {
+	Line     int    // 1-based line of the declaration
+	Selector string // Selector the declaration belongs to
+	Property string // Offending property
+	Message  string // Human readable explanation
+}
+
+// Properties the overlay controls on widget containers. Themes setting them
+// break dragging and resizing.
+var themeLayoutProperties = map[string]bool{
+	"position":        true,
+	"float":           true,
+	"top":             true,
+	"right":           true,
+	"bottom":          true,
+	"left":            true,
+	"inset":           true,
+	"inset-block":     true,
+	"inset-inline":    true,
+	"width":           true,
+	"height":          true,
+	"min-width":       true,
+	"min-height":      true,
+	"max-width":       true,
+	"max-height":      true,
+	"inline-size":     true,
+	"block-size":      true,
+	"min-inline-size": true,
+	"min-block-size":  true,
+	"max-inline-size": true,
+	"max-block-size":  true,
+}
+
+// Classes the overlay puts on widget containers. Ids starting with
+// "widget-" are matched as well.
+var themeWidgetClasses = map[string]bool{
+	"widget":         true,
+	"widget-content": true,
+}
+
+// cssDeclaration is a single "property: value" pair inside a rule
+type cssDeclaration struct {
+	Property string
+	Value    string
+	Line     int
+	Start    int // Byte offset of the declaration
+	End      int // Byte offset after the declaration (and its semicolon)
+}
+
+// cssRule is a style rule with its selector and declarations
+type cssRule struct {
+	Selector     string
+	Declarations []cssDeclaration
+}
+
+// LintTheme checks a theme's CSS for positioning and sizing rules on widget
+// containers
+func (s *StyleService) LintTheme(themeID string) ([]ThemeLintIssue, error) {
+	themeDir, err := s.themeDir(themeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return LintThemeFile(filepath.Join(themeDir, themeID+".css"))
+}
+
+// LintThemeFile checks a CSS file on disk for positioning and sizing rules
+// on widget containers
+func LintThemeFile(path string) ([]ThemeLintIssue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading theme CSS: %w", err)
+	}
+
+	return LintThemeCSS(string(content)), nil
+}
+
+// LintThemeCSS checks a stylesheet for positioning and sizing rules on
+// widget containers
+func LintThemeCSS(css string) []ThemeLintIssue {
+	issues := make([]ThemeLintIssue, 0)
+
+	for _, rule := range parseCSSRules(css) {
+		if !isWidgetContainerSelector(rule.Selector) {
+			continue
+		}
+
+		for _, decl := range rule.Declarations {
+			if !themeLayoutProperties[decl.Property] {
+				continue
+			}
+
+			issues = append(issues, ThemeLintIssue{
+				Line:     decl.Line,
+				Selector: rule.Selector,
+				Property: decl.Property,
+				Message: fmt.Sprintf("%q is set on widget container %q; widgets are positioned and sized by the overlay",
+					decl.Property, rule.Selector),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues
+}
+
+// stripThemeLayoutRules removes positioning and sizing declarations on widget
+// containers from a stylesheet
+func stripThemeLayoutRules(css string) string {
+	type span struct{ start, end int }
+	spans := make([]span, 0)
+
+	for _, rule := range parseCSSRules(css) {
+		if !isWidgetContainerSelector(rule.Selector) {
+			continue
+		}
+		for _, decl := range rule.Declarations {
+			if themeLayoutProperties[decl.Property] {
+				spans = append(spans, span{decl.Start, decl.End})
+			}
+		}
+	}
+
+	if len(spans) == 0 {
+		return css
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var sb strings.Builder
+	last := 0
+	for _, sp := range spans {
+		if sp.start < last {
+			continue
+		}
+		sb.WriteString(css[last:sp.start])
+		last = sp.end
+	}
+	sb.WriteString(css[last:])
+
+	return sb.String()
+}
+
+// isWidgetContainerSelector reports whether any selector in a selector list
+// targets a widget container itself (rather than something inside one)
+func isWidgetContainerSelector(selectorList string) bool {
+	for _, selector := range splitCSSTopLevel(selectorList, ',') {
+		compound := lastCompoundSelector(selector)
+
+		// Pseudo-elements are decorations, not the container
+		if strings.Contains(compound, "::") {
+			continue
+		}
+
+		for _, class := range selectorNames(compound, '.') {
+			if themeWidgetClasses[class] {
+				return true
+			}
+		}
+		for _, id := range selectorNames(compound, '#') {
+			if strings.HasPrefix(id, "widget-") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// lastCompoundSelector returns the subject of a complex selector, e.g.
+// ".widget:hover" for "#app > .widget:hover"
+func lastCompoundSelector(selector string) string {
+	selector = strings.TrimSpace(selector)
+	depth := 0
+	start := 0
+
+	for i := 0; i < len(selector); i++ {
+		switch c := selector[i]; c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ' ', '\t', '\n', '\r', '>', '+', '~':
+			if depth == 0 {
+				start = i + 1
+			}
+		}
+	}
+
+	return selector[start:]
+}
+
+// selectorNames returns the class or id names in a compound selector,
+// ignoring anything inside functional pseudo-classes such as :not()
+func selectorNames(compound string, prefix byte) []string {
+	names := make([]string, 0)
+	depth := 0
+
+	for i := 0; i < len(compound); i++ {
+		c := compound[i]
+		switch {
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == prefix && depth == 0:
+			j := i + 1
+			for j < len(compound) && isCSSNameChar(compound[j]) {
+				j++
+			}
+			names = append(names, compound[i+1:j])
+			i = j - 1
+		}
+	}
+
+	return names
+}
+
+func isCSSNameChar(c byte) bool {
+	return c == '-' || c == '_' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// splitCSSTopLevel splits on sep, ignoring separators inside brackets and
+// strings
+func splitCSSTopLevel(s string, sep byte) []string {
+	parts := make([]string, 0)
+	depth := 0
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// parseCSSRules is a small, forgiving CSS parser. It understands comments,
+// strings, grouping at-rules such as @media and nested rules, which is all
+// the linter needs. Other at-rules (@keyframes, @font-face...) are skipped.
+func parseCSSRules(css string) []cssRule {
+	p := &cssParser{src: css}
+	rules := make([]cssRule, 0)
+	p.parseRules(&rules, "", false)
+	return rules
+}
+
+type cssParser struct {
+	src string
+	pos int
+}
+
+// Grouping at-rules whose body is a list of ordinary rules
+var cssGroupingAtRules = map[string]bool{
+	"media":     true,
+	"supports":  true,
+	"layer":     true,
+	"container": true,
+	"document":  true,
+	"scope":     true,
+}
+
+// parseRules parses a list of rules until EOF, or the closing brace of the
+// enclosing block when nested is set
+func (p *cssParser) parseRules(rules *[]cssRule, parent string, nested bool) {
+	for {
+		p.skipSpaceAndComments()
+		if p.pos >= len(p.src) {
+			return
+		}
+
+		switch p.src[p.pos] {
+		case '}':
+			p.pos++
+			if nested {
+				return
+			}
+			continue
+		case '@':
+			p.parseAtRule(rules, parent)
+			continue
+		}
+
+		prelude, stop := p.readUntil("{;}")
+		switch stop {
+		case '{':
+			p.pos++
+			p.parseRuleBody(rules, resolveNestedSelector(parent, prelude))
+		case ';':
+			p.pos++
+		}
+	}
+}
+
+// parseAtRule handles an at-rule starting at the current position
+func (p *cssParser) parseAtRule(rules *[]cssRule, parent string) {
+	p.pos++
+	nameStart := p.pos
+	for p.pos < len(p.src) && isCSSNameChar(p.src[p.pos]) {
+		p.pos++
+	}
+	name := strings.ToLower(p.src[nameStart:p.pos])
+
+	_, stop := p.readUntil("{;}")
+	switch stop {
+	case ';':
+		p.pos++
+	case '{':
+		p.pos++
+		if cssGroupingAtRules[name] {
+			p.parseRules(rules, parent, true)
+		} else {
+			p.skipBlock()
+		}
+	}
+}
+
+// parseRuleBody parses the declarations of a style rule, after its opening
+// brace, through to its closing brace
+func (p *cssParser) parseRuleBody(rules *[]cssRule, selector string) {
+	rule := cssRule{Selector: selector}
+	index := len(*rules)
+	*rules = append(*rules, rule)
+
+	for {
+		p.skipSpaceAndComments()
+		if p.pos >= len(p.src) {
+			break
+		}
+
+		c := p.src[p.pos]
+		if c == '}' {
+			p.pos++
+			break
+		}
+		if c == ';' {
+			p.pos++
+			continue
+		}
+		if c == '@' {
+			p.parseAtRule(rules, selector)
+			continue
+		}
+
+		start := p.pos
+		text, stop := p.readUntil(";{}")
+		if stop == '{' {
+			p.pos++
+			p.parseRuleBody(rules, resolveNestedSelector(selector, text))
+			continue
+		}
+		if stop == ';' {
+			p.pos++
+		}
+
+		colon := strings.IndexByte(text, ':')
+		if colon < 0 {
+			continue
+		}
+
+		(*rules)[index].Declarations = append((*rules)[index].Declarations, cssDeclaration{
+			Property: strings.ToLower(strings.TrimSpace(text[:colon])),
+			Value:    strings.TrimSpace(text[colon+1:]),
+			Line:     strings.Count(p.src[:start], "\n") + 1,
+			Start:    start,
+			End:      p.pos,
+		})
+	}
+}
+
+// resolveNestedSelector combines a nested selector with its parent
+func resolveNestedSelector(parent, selector string) string {
+	selector = strings.TrimSpace(selector)
+	if parent == "" {
+		return selector
+	}
+
+	resolved := make([]string, 0)
+	for _, child := range splitCSSTopLevel(selector, ',') {
+		child = strings.TrimSpace(child)
+		for _, outer := range splitCSSTopLevel(parent, ',') {
+			outer = strings.TrimSpace(outer)
+			if strings.Contains(child, "&") {
+				resolved = append(resolved, strings.ReplaceAll(child, "&", outer))
+			} else {
+				resolved = append(resolved, outer+" "+child)
+			}
+		}
+	}
+
+	return strings.Join(resolved, ", ")
+}
+
+// readUntil reads up to (but not including) one of the stop characters at
+// bracket depth 0, skipping strings and comments. The returned text has
+// comments removed. The stop character is 0 at EOF.
+func (p *cssParser) readUntil(stops string) (string, byte) {
+	var sb strings.Builder
+	depth := 0
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		if c == '/' && strings.HasPrefix(p.src[p.pos:], "/*") {
+			p.skipComment()
+			continue
+		}
+
+		if c == '"' || c == '\'' {
+			start := p.pos
+			p.skipString()
+			sb.WriteString(p.src[start:p.pos])
+			continue
+		}
+
+		if depth == 0 && strings.IndexByte(stops, c) >= 0 {
+			return sb.String(), c
+		}
+
+		switch c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+		}
+
+		sb.WriteByte(c)
+		p.pos++
+	}
+
+	return sb.String(), 0
+}
+
+// skipBlock skips to just after the brace closing the current block
+func (p *cssParser) skipBlock() {
+	depth := 1
+	for p.pos < len(p.src) && depth > 0 {
+		switch c := p.src[p.pos]; {
+		case c == '/' && strings.HasPrefix(p.src[p.pos:], "/*"):
+			p.skipComment()
+			continue
+		case c == '"' || c == '\'':
+			p.skipString()
+			continue
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		}
+		p.pos++
+	}
+}
+
+func (p *cssParser) skipSpaceAndComments() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r', '\f':
+			p.pos++
+		case '/':
+			if !strings.HasPrefix(p.src[p.pos:], "/*") {
+				return
+			}
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *cssParser) skipComment() {
+	end := strings.Index(p.src[p.pos+2:], "*/")
+	if end < 0 {
+		p.pos = len(p.src)
+		return
+	}
+	p.pos += end + 4
+}
+
+func (p *cssParser) skipString() {
+	quote := p.src[p.pos]
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		if c == '\\' {
+			p.pos++
+		} else if c == quote || c == '\n' {
+			return
+		}
+	}
+	if p.pos > len(p.src) {
+		p.pos = len(p.src)
+	}
+}
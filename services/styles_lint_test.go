@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+var themeLintTests = []struct {
+	name  string
+	css   string
+	lint  []string // Issues as "line:property"
+	strip string
+}{
+	{
+		name:  "container",
+		css:   ".widget { width: 100px; color: red; }",
+		lint:  []string{"1:width"},
+		strip: ".widget {  color: red; }",
+	},
+	{
+		name:  "nested @media",
+		css:   "@media (max-width: 600px) {\n  .widget {\n    height: 10px;\n    color: red;\n  }\n}",
+		lint:  []string{"3:height"},
+		strip: "@media (max-width: 600px) {\n  .widget {\n    \n    color: red;\n  }\n}",
+	},
+	{
+		name:  "deeply nested at-rules",
+		css:   "@supports (display: grid) { @media screen { .widget-content { max-height: 5em; } } }",
+		lint:  []string{"1:max-height"},
+		strip: "@supports (display: grid) { @media screen { .widget-content {  } } }",
+	},
+	{
+		name:  "comments",
+		css:   "/* .widget { width: 1px } */\n.widget { /* top: 1px; */ left: 0; color: red }",
+		lint:  []string{"2:left"},
+		strip: "/* .widget { width: 1px } */\n.widget { /* top: 1px; */  color: red }",
+	},
+	{
+		name:  "braces in strings",
+		css:   ".widget { font-family: \"a{b}\"; left: 0 }\n[title=\"}\"] .widget { top: 0; }",
+		lint:  []string{"1:left", "2:top"},
+		strip: ".widget { font-family: \"a{b}\"; }\n[title=\"}\"] .widget {  }",
+	},
+	{
+		name:  "!important",
+		css:   ".widget { position: absolute !important; color: red !important; }",
+		lint:  []string{"1:position"},
+		strip: ".widget {  color: red !important; }",
+	},
+	{
+		name:  "selectors",
+		css:   ".widget span { width: 1px }\n#widget-chat { left: 0 }\n.widget:not(.big) { height: 1px }",
+		lint:  []string{"2:left", "3:height"},
+		strip: ".widget span { width: 1px }\n#widget-chat { }\n.widget:not(.big) { }",
+	},
+	{
+		name:  "@keyframes",
+		css:   "@keyframes slide { from { left: 0 } to { left: 10px } }",
+		strip: "@keyframes slide { from { left: 0 } to { left: 10px } }",
+	},
+}
+
+func TestLintThemeCSS(t *testing.T) {
+	for _, tt := range themeLintTests {
+		var got []string
+		for _, issue := range LintThemeCSS(tt.css) {
+			got = append(got, fmt.Sprintf("%d:%s", issue.Line, issue.Property))
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tt.lint) {
+			t.Errorf("%s: issues = %v, want %v", tt.name, got, tt.lint)
+		}
+	}
+}
+
+func TestStripThemeLayoutRules(t *testing.T) {
+	for _, tt := range themeLintTests {
+		if got := stripThemeLayoutRules(tt.css); got != tt.strip {
+			t.Errorf("%s: stripped = %q, want %q", tt.name, got, tt.strip)
+		}
+	}
+}
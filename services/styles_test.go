@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThemeChainCSS(t *testing.T) {
+	s := newTestStyleService(t, map[string][2]string{
+		"house": {`{"variables": [
+			{"name": "accent", "type": "color", "default": "#f80"},
+			{"name": "gap", "type": "length", "default": "8px"}
+		]}`, ".house { color: var(--accent) }"},
+		"event": {`{"parent": "house", "variables": [
+			{"name": "accent", "type": "color", "default": "#0af"}
+		]}`, ".event { color: red }"},
+		"night": {`{"parent": "event"}`, ".night { color: black }"},
+	})
+
+	css, err := s.GetOverlayThemeCSS("night")
+	if err != nil {
+		t.Fatalf("GetOverlayThemeCSS: %v", err)
+	}
+
+	house := strings.Index(css, ".house")
+	event := strings.Index(css, ".event")
+	night := strings.Index(css, ".night")
+	if house < 0 || event < house || night < event {
+		t.Fatalf("CSS isn't in parent-before-child order:\n%s", css)
+	}
+
+	variables, err := s.GetThemeVariables("night")
+	if err != nil {
+		t.Fatalf("GetThemeVariables: %v", err)
+	}
+	if len(variables) != 2 || variables[0].Value != "#0af" || variables[1].Value != "8px" {
+		t.Fatalf("variables = %+v, want accent from event and gap from house", variables)
+	}
+	if !strings.Contains(css, "--accent: #0af;") {
+		t.Fatalf("CSS doesn't use the child's accent:\n%s", css)
+	}
+}
+
+func TestThemeChainErrors(t *testing.T) {
+	s := newTestStyleService(t, map[string][2]string{
+		"house":   {`{"variables": [{"name": "accent", "type": "color", "default": "#f80"}]}`, ""},
+		"extra":   {`{"parent": "house", "variables": [{"name": "glow", "type": "color", "default": "#fff"}]}`, ""},
+		"a":       {`{"parent": "b"}`, ""},
+		"b":       {`{"parent": "a"}`, ""},
+		"self":    {`{"parent": "self"}`, ""},
+		"orphan":  {`{"parent": "missing"}`, ""},
+		"orphan2": {`{"parent": "orphan"}`, ""},
+	})
+
+	tests := []struct {
+		theme string
+		err   string
+	}{
+		{"extra", "declares variable glow, which parent theme house doesn't have"},
+		{"a", "inheritance cycle: a -> b -> a"},
+		{"self", "inheritance cycle: self -> self"},
+		{"orphan", "parent theme missing of orphan is not installed"},
+		{"orphan2", "parent theme missing of orphan is not installed"},
+	}
+
+	for _, tt := range tests {
+		if _, err := s.GetOverlayThemeCSS(tt.theme); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("GetOverlayThemeCSS(%s) = %v, want an error containing %q", tt.theme, err, tt.err)
+		}
+		if _, err := s.GetThemeVariables(tt.theme); err == nil {
+			t.Errorf("GetThemeVariables(%s) succeeded", tt.theme)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ThemeVariable is a CSS custom property declared by a theme manifest that
+// the user can tune from the settings window.
+type ThemeVariable struct {
+	Name    string // Property name without the leading "--"
+	Type    string // One of color, length, number, font or string
+	Default string // Value used when the user has no override
+	Label   string // Human readable label for the settings UI
+	Value   string // Current value (override or default)
+}
+
+var (
+	themeVariableNameRe   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	themeColorRe          = regexp.MustCompile(`^(#[0-9A-Fa-f]{3,4}|#[0-9A-Fa-f]{6}|#[0-9A-Fa-f]{8}|(rgb|rgba|hsl|hsla)\([0-9.,%\s/deg]+\)|[A-Za-z]+)$`)
+	themeLengthRe         = regexp.MustCompile(`^-?[0-9]*\.?[0-9]+(px|em|rem|%|vw|vh|pt)?$`)
+	themeNumberRe         = regexp.MustCompile(`^-?[0-9]*\.?[0-9]+$`)
+	themeUnsafeValueChars = ";{}<>\\\n\r"
+)
+
+// GetThemeVariables returns the variables declared by a theme, with the
+// user's overrides applied
+func (s *StyleService) GetThemeVariables(themeID string) ([]ThemeVariable, error) {
+	chain, err := s.resolveThemeChain(themeID)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := mergeThemeVariables(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.readThemeOverrides(themeID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range variables {
+		value, ok := overrides[variables[i].Name]
+		if !ok || validateThemeValue(variables[i].Type, value) != nil {
+			continue
+		}
+		variables[i].Value = value
+	}
+
+	return variables, nil
+}
+
+// SetThemeVariable stores a user override for one of a theme's variables
+func (s *StyleService) SetThemeVariable(themeID, name, value string) error {
+	chain, err := s.resolveThemeChain(themeID)
+	if err != nil {
+		return err
+	}
+
+	variables, err := mergeThemeVariables(chain)
+	if err != nil {
+		return err
+	}
+
+	var variable *ThemeVariable
+	for i := range variables {
+		if variables[i].Name == name {
+			variable = &variables[i]
+			break
+		}
+	}
+	if variable == nil {
+		return fmt.Errorf("theme %s has no variable named %s", themeID, name)
+	}
+
+	value = strings.TrimSpace(value)
+	if err := validateThemeValue(variable.Type, value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+
+	overrides, err := s.readThemeOverrides(themeID)
+	if err != nil {
+		return err
+	}
+	overrides[name] = value
+
+	return s.writeThemeOverrides(themeID, overrides)
+}
+
+// ResetThemeVariables removes all of the user's overrides for a theme
+func (s *StyleService) ResetThemeVariables(themeID string) error {
+	if _, err := s.themeDir(themeID); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.themeOverridesPath(themeID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error resetting theme variables: %w", err)
+	}
+
+	return nil
+}
+
+// mergeThemeVariables combines the variables of an inheritance chain. A
+// child theme redeclaring a variable replaces its parent's declaration, but
+// can't add variables the parent's CSS doesn't use.
+func mergeThemeVariables(chain []themeLayer) ([]ThemeVariable, error) {
+	variables := make([]ThemeVariable, 0)
+	index := make(map[string]int)
+
+	for n, layer := range chain {
+		declared, err := parseThemeVariables(layer.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s: %w", layer.ID, err)
+		}
+
+		for _, v := range declared {
+			if i, ok := index[v.Name]; ok {
+				variables[i] = v
+				continue
+			}
+			if n > 0 {
+				return nil, fmt.Errorf("theme %s declares variable %s, which parent theme %s doesn't have",
+					layer.ID, v.Name, chain[n-1].ID)
+			}
+			index[v.Name] = len(variables)
+			variables = append(variables, v)
+		}
+	}
+
+	return variables, nil
+}
+
+// parseThemeVariables reads the "variables" list from a theme manifest
+func parseThemeVariables(meta map[string]interface{}) ([]ThemeVariable, error) {
+	variables := make([]ThemeVariable, 0)
+
+	raw, ok := meta["variables"]
+	if !ok || raw == nil {
+		return variables, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return variables, err
+	}
+
+	var entries []struct {
+		Name    string
+		Type    string
+		Default interface{}
+		Label   string
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return variables, fmt.Errorf("variables must be a list: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := strings.TrimPrefix(entry.Name, "--")
+		if !themeVariableNameRe.MatchString(name) {
+			return variables, fmt.Errorf("invalid variable name %q", entry.Name)
+		}
+
+		varType := strings.ToLower(entry.Type)
+		if varType == "" {
+			varType = "string"
+		}
+
+		def := ""
+		if entry.Default != nil {
+			def = strings.TrimSpace(fmt.Sprint(entry.Default))
+		}
+		if err := validateThemeValue(varType, def); err != nil {
+			return variables, fmt.Errorf("invalid default for %s: %w", name, err)
+		}
+
+		label := entry.Label
+		if label == "" {
+			label = name
+		}
+
+		variables = append(variables, ThemeVariable{
+			Name:    name,
+			Type:    varType,
+			Default: def,
+			Label:   label,
+			Value:   def,
+		})
+	}
+
+	return variables, nil
+}
+
+// validateThemeValue checks a value against its declared type, and makes
+// sure it can't break out of the generated declaration block. An empty
+// value is allowed for every known type.
+func validateThemeValue(varType, value string) error {
+	// A comment opened by a value would swallow the rest of the stylesheet
+	if strings.ContainsAny(value, themeUnsafeValueChars) || strings.Contains(value, "/*") {
+		return fmt.Errorf("value contains forbidden characters")
+	}
+
+	switch varType {
+	case "color":
+		if value != "" && !themeColorRe.MatchString(value) {
+			return fmt.Errorf("%q is not a colour", value)
+		}
+	case "length":
+		if value != "" && !themeLengthRe.MatchString(value) {
+			return fmt.Errorf("%q is not a length", value)
+		}
+	case "number":
+		if value != "" && !themeNumberRe.MatchString(value) {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case "font", "string":
+	default:
+		return fmt.Errorf("unknown variable type %q", varType)
+	}
+
+	return nil
+}
+
+// buildThemeVariablesCSS renders the variables as a :root declaration block
+func buildThemeVariablesCSS(variables []ThemeVariable) string {
+	if len(variables) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(":root {\n")
+	for _, v := range variables {
+		if v.Value == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "  --%s: %s;\n", v.Name, v.Value)
+	}
+	sb.WriteString("}\n\n")
+
+	return sb.String()
+}
+
+// themeOverridesPath is the file holding the user's overrides for a theme
+func (s *StyleService) themeOverridesPath(themeID string) string {
+	return filepath.Join(s.overridesDir, themeID+".json")
+}
+
+// readThemeOverrides reads the user's overrides for a theme
+func (s *StyleService) readThemeOverrides(themeID string) (map[string]string, error) {
+	overrides := make(map[string]string)
+
+	content, err := os.ReadFile(s.themeOverridesPath(themeID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return overrides, nil
+		}
+		return nil, fmt.Errorf("error reading theme overrides: %w", err)
+	}
+
+	if len(content) == 0 {
+		return overrides, nil
+	}
+
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return nil, fmt.Errorf("error parsing theme overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// writeThemeOverrides saves the user's overrides for a theme
+func (s *StyleService) writeThemeOverrides(themeID string, overrides map[string]string) error {
+	content, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.overridesDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating theme overrides folder: %w", err)
+	}
+	if err := os.WriteFile(s.themeOverridesPath(themeID), content, 0644); err != nil {
+		return fmt.Errorf("error saving theme overrides: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestStyleService returns a StyleService over temporary folders with
+// the given themes installed
+func newTestStyleService(t *testing.T, themes map[string][2]string) *StyleService {
+	t.Helper()
+
+	dir := t.TempDir()
+	s := newStyleService(filepath.Join(dir, "themes"), filepath.Join(dir, "config", "theme-overrides"))
+	installTestThemes(t, s, themes)
+
+	return s
+}
+
+// installTestThemes writes the given themes, keyed by ID, into the themes
+// folder of s as meta.json and CSS contents
+func installTestThemes(t *testing.T, s *StyleService, themes map[string][2]string) {
+	t.Helper()
+
+	for id, files := range themes {
+		themeDir := filepath.Join(s.themesDir, id)
+		if err := os.MkdirAll(themeDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(themeDir, "meta.json"), []byte(files[0]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(themeDir, id+".css"), []byte(files[1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestValidateThemeValue(t *testing.T) {
+	tests := []struct {
+		varType string
+		value   string
+		ok      bool
+	}{
+		{"color", "#fff", true},
+		{"color", "#ff8800cc", true},
+		{"color", "rgba(0, 0, 0, 0.5)", true},
+		{"color", "hsl(120deg 50% 50%)", true},
+		{"color", "rebeccapurple", true},
+		{"color", "#ff88", true},
+		{"color", "#ff88001", false},
+		{"color", "url(evil.png)", false},
+		{"length", "12px", true},
+		{"length", "-0.5rem", true},
+		{"length", "100%", true},
+		{"length", "0", true},
+		{"length", "12 px", false},
+		{"length", "12furlongs", false},
+		{"number", "1.5", true},
+		{"number", "-2", true},
+		{"number", "1e3", false},
+		{"number", "two", false},
+		{"font", "'Roboto', sans-serif", true},
+		{"string", "anything goes", true},
+		{"string", "", true},
+		{"string", "red; } body { display: none", false},
+		{"string", "</style>", false},
+		{"string", "a\nb", false},
+		{"string", "/* swallow the rest", false},
+		{"font", "Roboto/*", false},
+		{"color", "", true},
+		{"gradient", "linear-gradient(red, blue)", false},
+		{"gradient", "", false},
+	}
+
+	for _, tt := range tests {
+		err := validateThemeValue(tt.varType, tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateThemeValue(%q, %q) = %v, want ok %v", tt.varType, tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestParseThemeVariables(t *testing.T) {
+	variables, err := parseThemeVariables(map[string]interface{}{
+		"variables": []interface{}{
+			map[string]interface{}{"name": "--accent", "type": "Color", "default": "#f80", "label": "Accent"},
+			map[string]interface{}{"name": "gap", "type": "length", "default": 8},
+			map[string]interface{}{"name": "title"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseThemeVariables: %v", err)
+	}
+
+	want := []ThemeVariable{
+		{Name: "accent", Type: "color", Default: "#f80", Label: "Accent", Value: "#f80"},
+		{Name: "gap", Type: "length", Default: "8", Label: "gap", Value: "8"},
+		{Name: "title", Type: "string", Default: "", Label: "title", Value: ""},
+	}
+	if len(variables) != len(want) {
+		t.Fatalf("got %d variables, want %d", len(variables), len(want))
+	}
+	for i := range want {
+		if variables[i] != want[i] {
+			t.Errorf("variable %d = %+v, want %+v", i, variables[i], want[i])
+		}
+	}
+
+	for _, bad := range []interface{}{
+		"accent",
+		[]interface{}{map[string]interface{}{"name": "bad name"}},
+		[]interface{}{map[string]interface{}{"name": "accent", "type": "color", "default": "not a colour!"}},
+		[]interface{}{map[string]interface{}{"name": "accent", "type": "gradient"}},
+	} {
+		if _, err := parseThemeVariables(map[string]interface{}{"variables": bad}); err == nil {
+			t.Errorf("parseThemeVariables(%v) succeeded", bad)
+		}
+	}
+}
+
+func TestSetThemeVariable(t *testing.T) {
+	s := newTestStyleService(t, map[string][2]string{
+		"neon": {`{"variables": [
+			{"name": "accent", "type": "color", "default": "#f80"},
+			{"name": "gap", "type": "length", "default": "8px"}
+		]}`, ""},
+	})
+
+	if err := s.SetThemeVariable("neon", "accent", " #0af "); err != nil {
+		t.Fatalf("SetThemeVariable: %v", err)
+	}
+	if err := s.SetThemeVariable("neon", "accent", "blue;}"); err == nil {
+		t.Fatal("SetThemeVariable accepted an unsafe value")
+	}
+	if err := s.SetThemeVariable("neon", "gap", "wide"); err == nil {
+		t.Fatal("SetThemeVariable accepted a length that isn't one")
+	}
+	if err := s.SetThemeVariable("neon", "missing", "1"); err == nil {
+		t.Fatal("SetThemeVariable accepted an unknown variable")
+	}
+	if err := s.SetThemeVariable("../neon", "accent", "#000"); err == nil {
+		t.Fatal("SetThemeVariable accepted a path as the theme ID")
+	}
+
+	variables, err := s.GetThemeVariables("neon")
+	if err != nil {
+		t.Fatalf("GetThemeVariables: %v", err)
+	}
+	if variables[0].Value != "#0af" || variables[1].Value != "8px" {
+		t.Fatalf("values = %s, %s, want #0af, 8px", variables[0].Value, variables[1].Value)
+	}
+
+	// Overrides live outside the theme folder, so updating the theme keeps them
+	if _, err := os.Stat(s.themeOverridesPath("neon")); err != nil {
+		t.Fatalf("overrides weren't saved to the config folder: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(s.themesDir, "neon")); err != nil {
+		t.Fatal(err)
+	}
+	installTestThemes(t, s, map[string][2]string{
+		"neon": {`{"variables": [{"name": "accent", "type": "color", "default": "#f00"}]}`, ""},
+	})
+	if variables, err := s.GetThemeVariables("neon"); err != nil || variables[0].Value != "#0af" {
+		t.Fatalf("after updating the theme: %+v, %v, want the override kept", variables, err)
+	}
+
+	if err := s.ResetThemeVariables("neon"); err != nil {
+		t.Fatalf("ResetThemeVariables: %v", err)
+	}
+	if variables, _ := s.GetThemeVariables("neon"); variables[0].Value != "#f00" {
+		t.Fatalf("value after reset = %s, want the default", variables[0].Value)
+	}
+}
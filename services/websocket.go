@@ -1,6 +1,7 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -12,6 +13,7 @@ type ServerService struct {
 	server     *http.Server
 	stopServer chan bool
 	upgrader   websocket.Upgrader
+	handler    socketHandler // Told about every message, set before the server starts
 }
 
 // NewServerService creates a new ServerService
@@ -78,5 +80,36 @@ func (s *ServerService) handleConnections(w http.ResponseWriter, r *http.Request
 			},
 		})
 
+		s.handler.handle(msgString)
 	}
 }
+
+// socketHandler is called with the event and data of each message from
+// Smash Soda
+type socketHandler func(event string, data interface{})
+
+// socketSource is a service that receives messages from Smash Soda
+type socketSource interface {
+	handleSocketMessages(handler socketHandler)
+}
+
+func (s *ServerService) handleSocketMessages(handler socketHandler) {
+	s.handler = handler
+}
+
+// handle decodes a message, {event, data}, and passes it to the handler
+func (h socketHandler) handle(message string) {
+	if h == nil {
+		return
+	}
+
+	var msg struct {
+		Event string      `json:"event"`
+		Data  interface{} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(message), &msg); err != nil || msg.Event == "" {
+		return
+	}
+
+	h(msg.Event, msg.Data)
+}
@@ -1,307 +1,290 @@
 package services
 
 import (
-	"bytes"
-	"encoding/base64"
 	"fmt"
-	"image"
-	"image/color"
-	"image/jpeg"
-	"sort"
-	"syscall"
-	"unsafe"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"github.com/wailsapp/wails/v3/pkg/events"
-	"golang.org/x/sys/windows"
 )
 
 type WindowService struct {
-	reset      bool
-	winapi     *winAPI
-	windowMode bool
+	reset     bool
+	winapi    *winAPI
+	monitors  MonitorSource
+	previews  previewCache
+	display   DisplayInfo // The monitor the overlay is on
+	target    string      // ID of the monitor the overlay was moved to
+	displayMu sync.Mutex
+	placed    Monitor                   // The monitor the window was last placed on
+	overlays  map[string]*overlayWindow // Extra overlays by monitor ID
+	moveMu    sync.Mutex                // Guards placed and overlays
+
+	newDisplayWatcher func() DisplayWatcher
+	displayWatcher    DisplayWatcher
+	watchMu           sync.Mutex // Serializes starting and stopping the watcher
+	displayTimer      *time.Timer
+	timerMu           sync.Mutex // Guards displayTimer, which the watcher sets
+
+	windowsMu sync.Mutex // Guards StudioWindow and HeadlessWindow
+
+	socketHandler socketHandler // Told about messages from Smash Soda, set at startup
+
+	hitRegions map[string][]HitRegion // Areas that take the mouse, by overlay
+	hitShaped  map[string]bool        // Overlays whose regions have been applied
+	focused    bool                   // Whether the overlays take the whole mouse
+	hitMu      sync.Mutex             // Guards the above, taken before moveMu
+
+	screenshotFolder string // Where screenshots are saved, or "" for the default
+	screenshotMu     sync.Mutex
+
+	mode      string // One of the WindowMode constants
+	modePath  string // Where the mode is saved, or "" to not save it
+	modeMu    sync.Mutex
+	opacity   float64 // Fade applied on top of the user's opacity setting
+	opacityMu sync.Mutex
 }
 
-type MonitorInfoEx struct {
-	CbSize    uint32
-	RcMonitor windows.Rect
-	RcWork    windows.Rect
-	DwFlags   uint32
-	Device    [32]uint16 // Display device name (WCHAR[32])
-}
+// EventWindowOpacity is emitted with {opacity} when SetOpacity changes the
+// overlay's fade
+const EventWindowOpacity = "window:opacity"
 
-// Monitor represents a display monitor with its properties.
-type Monitor struct {
-	Name    string // Display name (e.g., "[1] 1920x1080")
-	Width   int    // Width of the monitor
-	Height  int    // Height of the monitor
-	X       int    // X-coordinate of the monitor's position
-	Y       int    // Y-coordinate of the monitor's position
-	Preview string // Base64-encoded image preview of the monitor
-}
+// NewWindowService creates a new WindowService in the saved mode. If
+// windowMode is set, it starts windowed regardless.
+func NewWindowService(windowMode bool) *WindowService {
+	path := windowModePath()
 
-type winAPI struct {
-	user32                 *windows.LazyDLL
-	gdi32                  *windows.LazyDLL
-	dwmapi                 *windows.LazyDLL
-	getDC                  *windows.LazyProc
-	releaseDC              *windows.LazyProc
-	createCompatibleDC     *windows.LazyProc
-	createCompatibleBitmap *windows.LazyProc
-	selectObject           *windows.LazyProc
-	bitBlt                 *windows.LazyProc
-	deleteDC               *windows.LazyProc
-	deleteObject           *windows.LazyProc
-	getDIBits              *windows.LazyProc
-	dwmGetWindowAttribute  *windows.LazyProc
-}
+	mode := loadWindowMode(path)
+	if windowMode {
+		mode = WindowModeWindowed
+	}
 
-// Load GetMonitorInfo from user32.dll
-var (
-	user32Window            = syscall.NewLazyDLL("user32.dll")
-	procEnumDisplayMonitors = user32Window.NewProc("EnumDisplayMonitors")
-	procGetMonitorInfo      = user32Window.NewProc("GetMonitorInfoW")
-)
+	ws := newWindowService(mode, newMonitorSource())
+	ws.modePath = path
 
-// NewWindowService creates a new WindowService
-func NewWindowService(windowMode bool) *WindowService {
+	return ws
+}
+
+// newWindowService creates a WindowService that enumerates monitors with
+// the given source, so that tests can swap it out
+func newWindowService(mode string, monitors MonitorSource) *WindowService {
 	// Create a new WindowService
 	ws := &WindowService{
-		winapi: &winAPI{
-			user32: windows.NewLazySystemDLL("user32.dll"),
-			gdi32:  windows.NewLazySystemDLL("gdi32.dll"),
-			dwmapi: windows.NewLazySystemDLL("dwmapi.dll"),
-		},
-		windowMode: windowMode,
+		winapi:     newWinAPI(),
+		monitors:   monitors,
+		overlays:   make(map[string]*overlayWindow),
+		hitRegions: make(map[string][]HitRegion),
+		hitShaped:  make(map[string]bool),
+		mode:       mode,
+		opacity:    1,
 	}
-	ws.winapi.getDC = ws.winapi.user32.NewProc("GetDC")
-	ws.winapi.releaseDC = ws.winapi.user32.NewProc("ReleaseDC")
-	ws.winapi.createCompatibleDC = ws.winapi.gdi32.NewProc("CreateCompatibleDC")
-	ws.winapi.createCompatibleBitmap = ws.winapi.gdi32.NewProc("CreateCompatibleBitmap")
-	ws.winapi.selectObject = ws.winapi.gdi32.NewProc("SelectObject")
-	ws.winapi.bitBlt = ws.winapi.gdi32.NewProc("BitBlt")
-	ws.winapi.deleteDC = ws.winapi.gdi32.NewProc("DeleteDC")
-	ws.winapi.deleteObject = ws.winapi.gdi32.NewProc("DeleteObject")
-	ws.winapi.getDIBits = ws.winapi.gdi32.NewProc("GetDIBits")
-	ws.winapi.dwmGetWindowAttribute = ws.winapi.dwmapi.NewProc("DwmGetWindowAttribute")
 
 	return ws
 }
 
-// GetMonitors retrieves the list of monitors connected to the system.
+// GetMonitors retrieves the list of monitors connected to the system, left
+// to right. Previews are fetched separately with GetMonitorPreview.
 func (ws *WindowService) GetMonitors() []Monitor {
+	monitors := ws.listMonitors()
 
-	var monitors []Monitor
-	//var primaryMonitor Monitor
-
-	// Callback function to populate the monitors slice
-	monitorEnumProc := func(hMonitor windows.Handle, hdcMonitor windows.Handle, lprcMonitor *windows.Rect, dwData uintptr) uintptr {
-		var mi MonitorInfoEx
-		mi.CbSize = uint32(unsafe.Sizeof(mi))
-
-		// Call GetMonitorInfo via Windows API
-		r1, _, _ := procGetMonitorInfo.Call(uintptr(hMonitor), uintptr(unsafe.Pointer(&mi)))
-		if r1 != 0 {
-
-			// Calculate width and height
-			width := int(mi.RcMonitor.Right - mi.RcMonitor.Left)
-			height := int(mi.RcMonitor.Bottom - mi.RcMonitor.Top)
-			x := int(mi.RcMonitor.Left)
-			y := int(mi.RcMonitor.Top)
-
-			// Get the device name
-			deviceName := fmt.Sprintf("[%d] %dx%d", len(monitors)+1, width, height)
-
-			// Get a preview of the monitor
-			preview, err := ws.captureMonitorPreview(x, y, width, height)
-			if err != nil {
-				fmt.Println("Preview error:", err)
-			}
-
-			// Create a Monitor struct
-			monitor := Monitor{
-				Name:    deviceName,
-				Width:   width,
-				Height:  height,
-				X:       x,
-				Y:       y,
-				Preview: preview,
-			}
-
-			monitors = append(monitors, monitor)
-
-			// Print monitor details
-			fmt.Printf("Monitor %d: %s (%dx%d) at (%d, 0)\n", len(monitors), deviceName, width, height, x)
-		}
-		return 1 // Continue enumeration
-	}
-
-	// Call EnumDisplayMonitors with the callback
-	r1, _, _ := procEnumDisplayMonitors.Call(0, 0, syscall.NewCallback(monitorEnumProc), 0)
-	if r1 == 0 {
-		fmt.Println("Failed to enumerate displays")
+	for i, m := range monitors {
+		fmt.Printf("Monitor %d: %s %s (%dx%d) at (%d, %d)\n", i+1, m.ID, m.Name, m.Width, m.Height, m.X, m.Y)
 	}
 
-	// Sort monitors by position (Left)
-	sort.Slice(monitors, func(i, j int) bool {
-		return monitors[i].X < monitors[j].X
-	})
-
 	return monitors
-
 }
 
-// captureMonitorPreview captures a preview image of the monitor using GDI.
-func (ws *WindowService) captureMonitorPreview(x, y, width, height int) (string, error) {
-	win := ws.winapi
-
-	// Get screen device context
-	hdcScreen, _, _ := win.getDC.Call(0)
-	hdcMem, _, _ := win.createCompatibleDC.Call(hdcScreen)
-	hbm, _, _ := win.createCompatibleBitmap.Call(hdcScreen, uintptr(width), uintptr(height))
-	win.selectObject.Call(hdcMem, hbm)
-
-	// Copy screen into bitmap
-	const SRCCOPY = 0x00CC0020
-	win.bitBlt.Call(hdcMem, 0, 0, uintptr(width), uintptr(height), hdcScreen, uintptr(x), uintptr(y), SRCCOPY)
-
-	// Create BITMAPINFO
-	type BITMAPINFOHEADER struct {
-		Size          uint32
-		Width         int32
-		Height        int32
-		Planes        uint16
-		BitCount      uint16
-		Compression   uint32
-		SizeImage     uint32
-		XPelsPerMeter int32
-		YPelsPerMeter int32
-		ClrUsed       uint32
-		ClrImportant  uint32
-	}
-
-	type BITMAPINFO struct {
-		Header BITMAPINFOHEADER
-		Colors [1]uint32
+// Blur the overlay windows
+func (ws *WindowService) Blur() {
+	if !ws.overlayMode() {
+		return
 	}
-
-	bi := BITMAPINFO{
-		Header: BITMAPINFOHEADER{
-			Size:        uint32(unsafe.Sizeof(BITMAPINFOHEADER{})),
-			Width:       int32(width),
-			Height:      -int32(height), // top-down DIB
-			Planes:      1,
-			BitCount:    32,
-			Compression: 0, // BI_RGB
-		},
+	for _, w := range ws.overlayWindows() {
+		w.SetIgnoreMouseEvents(true)
 	}
 
-	// Create image buffer
-	bufLen := width * height * 4
-	buf := make([]byte, bufLen)
+	// Hit regions keep taking the mouse
+	ws.setFocused(false)
+}
 
-	ret, _, _ := win.getDIBits.Call(hdcMem, hbm, 0, uintptr(height),
-		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&bi)), 0)
+// Focus the main overlay window
+func (ws *WindowService) Focus() {
+	ws.FocusOverlay("")
+}
 
-	if ret == 0 {
-		return "", fmt.Errorf("GetDIBits failed")
+// FocusOverlay lets the mouse through to every overlay and focuses the one
+// with the given monitor ID, or the main overlay if it is empty
+func (ws *WindowService) FocusOverlay(id string) {
+	if !ws.overlayMode() {
+		return
 	}
 
-	// Convert raw BGRA bytes to RGBA
-	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
-	for i := 0; i < len(buf); i += 4 {
-		y := (i / 4) / width
-		x := (i / 4) % width
-		b, g, r, a := buf[i], buf[i+1], buf[i+2], buf[i+3]
-		rgba.Set(x, y, color.NRGBA{R: r, G: g, B: b, A: a})
+	focus := WailsWindow
+	if id != "" {
+		ws.moveMu.Lock()
+		if o, ok := ws.overlays[id]; ok && o.shown {
+			focus = o.window
+		}
+		ws.moveMu.Unlock()
 	}
 
-	// Cleanup GDI
-	win.deleteObject.Call(hbm)
-	win.deleteDC.Call(hdcMem)
-	win.releaseDC.Call(0, hdcScreen)
-
-	// Encode PNG to base64 in a goroutine
-	previewCh := make(chan string, 1)
-	go func() {
-		var pngBuf bytes.Buffer
-		if err := jpeg.Encode(&pngBuf, rgba, &jpeg.Options{Quality: 80}); err != nil {
-			fmt.Println("PNG encode failed:", err)
-			previewCh <- ""
-			return
-		}
-		dataURI := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(pngBuf.Bytes())
-		previewCh <- dataURI
-	}()
+	ws.setFocused(true)
 
-	preview := <-previewCh
-	return preview, nil
+	for _, w := range ws.overlayWindows() {
+		w.SetIgnoreMouseEvents(false)
+	}
+	focus.Focus()
 }
 
-// Blur the overlay window
-func (ws *WindowService) Blur() {
-	if ws.windowMode {
-		return
+// SetOpacity fades the overlay between 0 (hidden) and 1 (fully shown). It
+// multiplies the user's opacity setting rather than replacing it.
+func (ws *WindowService) SetOpacity(opacity float64) {
+	if opacity < 0 {
+		opacity = 0
+	} else if opacity > 1 {
+		opacity = 1
 	}
-	WailsWindow.SetIgnoreMouseEvents(true)
-}
 
-// Focus the overlay window
-func (ws *WindowService) Focus() {
-	if ws.windowMode {
+	ws.opacityMu.Lock()
+	if ws.opacity == opacity {
+		ws.opacityMu.Unlock()
 		return
 	}
-	WailsWindow.SetIgnoreMouseEvents(false)
-	WailsWindow.Focus()
+	ws.opacity = opacity
+	ws.opacityMu.Unlock()
+
+	emitAppEvent(EventWindowOpacity, map[string]interface{}{
+		"opacity": opacity,
+	})
+}
+
+// GetOpacity returns the fade set by SetOpacity
+func (ws *WindowService) GetOpacity() float64 {
+	ws.opacityMu.Lock()
+	defer ws.opacityMu.Unlock()
+
+	return ws.opacity
 }
 
+// GetResolution returns the logical size of the primary monitor, which
+// is what window options are measured in
 func (ws *WindowService) GetResolution() (int, int) {
-	monitor := ws.GetMonitors()[0]
-	return monitor.Width, monitor.Height
+	monitors := ws.listMonitors()
+	if len(monitors) == 0 {
+		return 0, 0
+	}
+	monitor := monitors[resolveMonitor(monitors, "")]
+	return monitor.logical(monitor.Width), monitor.logical(monitor.Height)
 }
 
-// Reset has default value of false
+// MoveMainWindowToMonitor moves the overlay to a monitor by its index in
+// GetMonitors. Prefer MoveMainWindowToDisplay, as indexes change when
+// monitors are rearranged.
 func (ws *WindowService) MoveMainWindowToMonitor(index int) {
 
 	// Get the list of monitors
-	monitors := ws.GetMonitors()
+	monitors := ws.listMonitors()
 	if index < 0 || index >= len(monitors) {
 		fmt.Println("Invalid monitor index")
 		return
 	}
 
-	monitor := monitors[index]
+	ws.setTarget(monitors[index].ID)
+	ws.moveMainWindow(monitors, index)
+}
 
-	if ws.reset && !ws.windowMode {
-		WailsWindow.ToggleFullscreen()
+// MoveMainWindowToDisplay moves the overlay to the monitor with the given
+// ID. If it isn't connected, a similar monitor or the primary one is used
+// instead. The monitor moved to is returned.
+func (ws *WindowService) MoveMainWindowToDisplay(id string) (Monitor, error) {
+	monitors := ws.listMonitors()
+
+	index := resolveMonitor(monitors, id)
+	if index < 0 {
+		return Monitor{}, fmt.Errorf("no monitors found")
+	}
+
+	if monitors[index].ID != id {
+		fmt.Printf("Display %s not found, using %s\n", id, monitors[index].ID)
+	}
+
+	// Remember the monitor asked for, so the overlay goes back to it when
+	// it is plugged in again
+	ws.setTarget(id)
+	ws.moveMainWindow(monitors, index)
+
+	return monitors[index], nil
+}
+
+func (ws *WindowService) setTarget(id string) {
+	ws.displayMu.Lock()
+	defer ws.displayMu.Unlock()
+
+	ws.target = id
+}
+
+// moveMainWindow places the overlay on monitors[index]. Extra overlays
+// make way if it is moved onto one of their monitors.
+func (ws *WindowService) moveMainWindow(monitors []Monitor, index int) {
+	ws.moveMu.Lock()
+	ws.moveMainWindowLocked(index, monitors[index])
+	ws.placeOverlaysLocked(monitors)
+	ws.moveMu.Unlock()
+
+	ws.refreshHitRegions()
+}
+
+// moveMainWindowLocked places the overlay on a monitor. ws.moveMu must be
+// held. Reset has default value of false.
+func (ws *WindowService) moveMainWindowLocked(index int, monitor Monitor) {
+
+	overlay := ws.overlayMode()
+
+	// Fullscreen windows can't be moved
+	if ws.reset && overlay {
+		WailsWindow.UnFullscreen()
 	}
 
 	// Move the window to the monitor
-	//win.SetWindowPos(hwnd, 0, int32(monitor.X), int32(0), int32(monitor.Width), int32(monitor.Height), win.SWP_NOSIZE|win.SWP_NOZORDER)
-	WailsWindow.SetPosition(monitor.X, monitor.Y)
-	WailsWindow.SetSize(monitor.Width, monitor.Height)
+	if err := placeWindow(WailsWindow, monitor); err != nil {
+		fmt.Println("Error moving window:", err)
+	}
 
-	fmt.Printf("Moved window to monitor %d: %s (%d x %d) (x: %d, y: %d)\n", index, monitor.Name, monitor.Width, monitor.Height, monitor.X, monitor.Y)
+	fmt.Printf("Moved window to monitor %d: %s (%d x %d) (x: %d, y: %d, scale: %g)\n", index, monitor.Name, monitor.Width, monitor.Height, monitor.X, monitor.Y, monitor.Scale)
 
-	if !ws.windowMode {
+	if overlay {
 		WailsWindow.Fullscreen().Maximise()
 		WailsWindow.SetAlwaysOnTop(true)
 	}
 
 	// Reset on next call
 	ws.reset = true
+	ws.placed = monitor
+
+	ws.setDisplay(monitor)
 
 }
 
-// CreateStudioWindow creates a new window for the overlay customization
+// EventWindowClosed is emitted with {name} when the studio or headless
+// window is closed
+const EventWindowClosed = "window:closed"
+
+// CreateStudioWindow creates a new window for the overlay customization, or
+// brings the open one to the front
 func (ws *WindowService) CreateStudioWindow() {
-	// Do nothing if the overlay window already exists
+	ws.windowsMu.Lock()
+	defer ws.windowsMu.Unlock()
+
 	if StudioWindow != nil {
+		StudioWindow.Show()
+		StudioWindow.Focus()
 		return
 	}
 
 	// Create a new studio window
 	StudioWindow = WailsApp.Window.NewWithOptions(application.WebviewWindowOptions{
+		Name:                       "studio",
 		Title:                      "Smash Soda Studio",
 		URL:                        "/?mode=studio",
 		Zoom:                       1,
@@ -314,14 +297,15 @@ func (ws *WindowService) CreateStudioWindow() {
 	StudioWindow.Maximise()
 
 	// Cleanup
-	StudioWindow.RegisterHook(events.Common.WindowClosing, func(e *application.WindowEvent) {
-
-	})
-
+	ws.forgetOnClose(StudioWindow, "studio", &StudioWindow)
 }
 
-// CreateStudioWindow creates the headless overlay window
+// CreateHeadlessWindow creates the headless overlay window. It is hidden
+// until ShowHeadlessWindow is called.
 func (ws *WindowService) CreateHeadlessWindow() {
+	ws.windowsMu.Lock()
+	defer ws.windowsMu.Unlock()
+
 	// Do nothing if the overlay window already exists
 	if HeadlessWindow != nil {
 		return
@@ -329,6 +313,7 @@ func (ws *WindowService) CreateHeadlessWindow() {
 
 	// Create a new overlay window
 	HeadlessWindow = WailsApp.Window.NewWithOptions(application.WebviewWindowOptions{
+		Name:                       "headless",
 		Title:                      "Smash Soda Headless",
 		BackgroundType:             application.BackgroundTypeTransparent,
 		BackgroundColour:           application.NewRGBA(0, 0, 0, 0),
@@ -345,6 +330,68 @@ func (ws *WindowService) CreateHeadlessWindow() {
 		Hidden:                     true,
 	})
 
+	// Cleanup
+	ws.forgetOnClose(HeadlessWindow, "headless", &HeadlessWindow)
+}
+
+// ShowHeadlessWindow shows or hides the headless window, creating it first
+// if needed
+func (ws *WindowService) ShowHeadlessWindow(visible bool) {
+	if visible {
+		ws.CreateHeadlessWindow()
+	}
+
+	ws.windowsMu.Lock()
+	w := HeadlessWindow
+	ws.windowsMu.Unlock()
+
+	if w == nil {
+		return
+	}
+	if visible {
+		w.Show()
+	} else {
+		w.Hide()
+	}
+}
+
+// CloseStudioWindow closes the studio window if it is open
+func (ws *WindowService) CloseStudioWindow() {
+	ws.closeWindow(&StudioWindow)
+}
+
+// CloseHeadlessWindow closes the headless window if it is open
+func (ws *WindowService) CloseHeadlessWindow() {
+	ws.closeWindow(&HeadlessWindow)
+}
+
+func (ws *WindowService) closeWindow(global **application.WebviewWindow) {
+	ws.windowsMu.Lock()
+	w := *global
+	ws.windowsMu.Unlock()
+
+	// Closing runs the hook set by forgetOnClose, which takes the lock
+	if w != nil {
+		w.Close()
+	}
+}
+
+// forgetOnClose clears a window's global once it closes, so that it can be
+// created again, and emits window:closed
+func (ws *WindowService) forgetOnClose(w *application.WebviewWindow, name string, global **application.WebviewWindow) {
+	w.RegisterHook(events.Common.WindowClosing, func(e *application.WindowEvent) {
+		ws.windowsMu.Lock()
+		if *global == w {
+			*global = nil
+		}
+		ws.windowsMu.Unlock()
+
+		fmt.Printf("Closed %s window\n", name)
+
+		emitAppEvent(EventWindowClosed, map[string]interface{}{
+			"name": name,
+		})
+	})
 }
 
 // Just a backend way to log messages
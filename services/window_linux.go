@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/jezek/xgb/xproto"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// winAPI holds the Windows GDI procedures used for previews. There is
+// nothing to load on Linux.
+type winAPI struct{}
+
+func newWinAPI() *winAPI {
+	return nil
+}
+
+// captureScreen copies an area of the root window into an outWidth x
+// outHeight image. The X server can't scale, so the pixels are sampled
+// down before the image is built.
+func (ws *WindowService) captureScreen(x, y, width, height, outWidth, outHeight int) (*image.RGBA, error) {
+	d, err := openX11Display()
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	screen := xproto.Setup(d.conn).DefaultScreen(d.conn)
+	if screen.RootDepth != 24 && screen.RootDepth != 32 {
+		return nil, fmt.Errorf("unsupported screen depth %d", screen.RootDepth)
+	}
+
+	reply, err := xproto.GetImage(d.conn, xproto.ImageFormatZPixmap, xproto.Drawable(d.root),
+		int16(x), int16(y), uint16(width), uint16(height), 0xFFFFFFFF).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("error getting image: %w", err)
+	}
+	if len(reply.Data) < width*height*4 {
+		return nil, fmt.Errorf("image is too short")
+	}
+
+	// ZPixmap data at depth 24 and 32 is BGRX, four bytes a pixel
+	rgba := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	if outWidth == width && outHeight == height {
+		copy(rgba.Pix, reply.Data)
+	} else {
+		for oy := 0; oy < outHeight; oy++ {
+			row := reply.Data[(oy*height/outHeight)*width*4:]
+			out := rgba.Pix[oy*rgba.Stride:]
+			for ox := 0; ox < outWidth; ox++ {
+				copy(out[ox*4:ox*4+4], row[(ox*width/outWidth)*4:])
+			}
+		}
+	}
+
+	bgraToRGBA(rgba.Pix)
+
+	return rgba, nil
+}
+
+// placeWindow moves a window onto a monitor and sizes it to fill it. GTK
+// measures windows in logical pixels.
+func placeWindow(w *application.WebviewWindow, m Monitor) error {
+	w.SetPosition(m.logical(m.X), m.logical(m.Y))
+	w.SetSize(m.logical(m.Width), m.logical(m.Height))
+	return nil
+}
@@ -0,0 +1,70 @@
+package services
+
+// WindowInfo describes a window on the desktop
+type WindowInfo struct {
+	Handle    uint64       `json:"handle"` // Stays the same for the life of the window
+	Title     string       `json:"title"`
+	Class     string       `json:"class"`
+	PID       uint32       `json:"pid"`
+	Process   string       `json:"process"` // Executable name, e.g. "game.exe"
+	Visible   bool         `json:"visible"`
+	TopLevel  bool         `json:"topLevel"` // An application window rather than a tool or owned window
+	Minimized bool         `json:"minimized"`
+	Bounds    WindowBounds `json:"bounds"`
+	Monitor   int          `json:"monitor"` // Index into GetMonitors, or -1
+}
+
+// WindowBounds is the position and size of a window or area in screen pixels
+type WindowBounds struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// WindowFilter narrows down the windows returned by GetWindows
+type WindowFilter struct {
+	VisibleOnly  bool `json:"visibleOnly"`  // Skip hidden and cloaked windows
+	TopLevelOnly bool `json:"topLevelOnly"` // Skip tool windows and windows owned by another window
+	TitledOnly   bool `json:"titledOnly"`   // Skip windows without a title
+}
+
+// GetWindows lists the windows on the desktop, front to back
+func (hs *HookService) GetWindows(filter WindowFilter) ([]WindowInfo, error) {
+	all, err := listWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]WindowInfo, 0, len(all))
+	for _, w := range all {
+		if filter.VisibleOnly && !w.Visible {
+			continue
+		}
+		if filter.TopLevelOnly && !w.TopLevel {
+			continue
+		}
+		if filter.TitledOnly && w.Title == "" {
+			continue
+		}
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+// GetWindowNames returns the titles of the visible application windows,
+// for picking the game window
+func (hs *HookService) GetWindowNames() ([]string, error) {
+	windows, err := hs.GetWindows(WindowFilter{VisibleOnly: true, TopLevelOnly: true, TitledOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	windowNames := make([]string, 0, len(windows))
+	for _, w := range windows {
+		windowNames = append(windowNames, w.Title)
+	}
+
+	return windowNames, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bytes"
+
+	"github.com/jezek/xgb/xproto"
+)
+
+// Atoms read by listWindows
+var windowListAtoms = []string{
+	"_NET_CLIENT_LIST_STACKING",
+	"_NET_WM_STATE",
+	"_NET_WM_STATE_HIDDEN",
+	"_NET_WM_STATE_SKIP_TASKBAR",
+	"_NET_WM_WINDOW_TYPE",
+	"_NET_WM_WINDOW_TYPE_NORMAL",
+}
+
+// listWindows enumerates the client windows the window manager keeps in
+// _NET_CLIENT_LIST_STACKING
+func listWindows() ([]WindowInfo, error) {
+	d, err := openX11Display(append(windowListAtoms, monitorAtoms...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	monitors, _ := d.monitors()
+	sortMonitors(monitors)
+
+	// The list runs bottom to top, GetWindows returns front to back
+	clients := d.atomList(d.root, d.atoms["_NET_CLIENT_LIST_STACKING"], xproto.AtomWindow)
+	list := make([]WindowInfo, 0, len(clients))
+	for i := len(clients) - 1; i >= 0; i-- {
+		list = append(list, d.describeWindow(xproto.Window(clients[i]), monitors))
+	}
+
+	return list, nil
+}
+
+// describeWindow gathers everything WindowInfo needs about a window
+func (d *x11Display) describeWindow(win xproto.Window, monitors []Monitor) WindowInfo {
+	focus := d.focusInfo(win)
+
+	info := WindowInfo{
+		Handle:  uint64(win),
+		Title:   focus.Title,
+		PID:     focus.PID,
+		Process: focus.Process,
+	}
+
+	// WM_CLASS holds the instance and class names, each null terminated
+	if class := bytes.Split(d.property(win, xproto.AtomWmClass, xproto.AtomString), []byte{0}); len(class) > 1 {
+		info.Class = string(class[1])
+	}
+
+	skipTaskbar := false
+	for _, state := range d.atomList(win, d.atoms["_NET_WM_STATE"], xproto.AtomAtom) {
+		switch xproto.Atom(state) {
+		case d.atoms["_NET_WM_STATE_HIDDEN"]:
+			info.Minimized = true
+		case d.atoms["_NET_WM_STATE_SKIP_TASKBAR"]:
+			skipTaskbar = true
+		}
+	}
+
+	normal := true
+	if types := d.atomList(win, d.atoms["_NET_WM_WINDOW_TYPE"], xproto.AtomAtom); len(types) > 0 {
+		normal = xproto.Atom(types[0]) == d.atoms["_NET_WM_WINDOW_TYPE_NORMAL"]
+	}
+	owned := len(d.property(win, xproto.AtomWmTransientFor, xproto.AtomWindow)) >= 4
+	info.TopLevel = normal && !owned && !skipTaskbar
+
+	// Windows on other workspaces are unmapped, minimized windows count as
+	// visible like they do on Windows
+	if attrs, err := xproto.GetWindowAttributes(d.conn, win).Reply(); err == nil {
+		info.Visible = attrs.MapState == xproto.MapStateViewable || info.Minimized
+	}
+
+	if geom, err := xproto.GetGeometry(d.conn, xproto.Drawable(win)).Reply(); err == nil {
+		info.Bounds.Width = int(geom.Width)
+		info.Bounds.Height = int(geom.Height)
+	}
+	if pos, err := xproto.TranslateCoordinates(d.conn, win, d.root, 0, 0).Reply(); err == nil {
+		info.Bounds.X = int(pos.DstX)
+		info.Bounds.Y = int(pos.DstY)
+	}
+
+	info.Monitor = monitorAt(monitors, info.Bounds.X+info.Bounds.Width/2, info.Bounds.Y+info.Bounds.Height/2)
+
+	return info
+}
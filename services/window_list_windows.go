@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	getWindowLongPtr   = user32.NewProc("GetWindowLongPtrW")
+	getWindowRect      = user32.NewProc("GetWindowRect")
+	getWindowOwner     = user32.NewProc("GetWindow")
+	isIconic           = user32.NewProc("IsIconic")
+	monitorFromWindow  = user32.NewProc("MonitorFromWindow")
+	dwmapi             = windows.NewLazySystemDLL("dwmapi.dll")
+	dwmGetWindowAttrib = dwmapi.NewProc("DwmGetWindowAttribute")
+)
+
+const (
+	GWL_EXSTYLE              = -20
+	GW_OWNER                 = 4
+	WS_EX_TOOLWINDOW         = 0x00000080
+	WS_EX_APPWINDOW          = 0x00040000
+	DWMWA_CLOAKED            = 14
+	MONITOR_DEFAULTTONEAREST = 2
+)
+
+// windowEnumCallback is created once, as Windows callbacks are never
+// freed. It adds each window to enumeratedWindows.
+var (
+	enumeratedWindows   []uintptr
+	enumeratedWindowsMu sync.Mutex
+	windowEnumCallback  = syscall.NewCallback(func(hwnd uintptr, lParam uintptr) uintptr {
+		enumeratedWindows = append(enumeratedWindows, hwnd)
+		return 1 // Continue enumeration
+	})
+)
+
+// listWindows enumerates the top-level windows with EnumWindows
+func listWindows() ([]WindowInfo, error) {
+	monitors, _ := newMonitorSource().Monitors()
+	sortMonitors(monitors)
+
+	enumeratedWindowsMu.Lock()
+	enumeratedWindows = nil
+
+	ret, _, err := enumWindowsProc.Call(windowEnumCallback, 0)
+
+	handles := enumeratedWindows
+	enumeratedWindows = nil
+	enumeratedWindowsMu.Unlock()
+
+	if ret == 0 {
+		return nil, fmt.Errorf("EnumWindows failed: %v", err)
+	}
+
+	list := make([]WindowInfo, 0, len(handles))
+	for _, hwnd := range handles {
+		list = append(list, describeWindow(hwnd, monitors))
+	}
+
+	return list, nil
+}
+
+// describeWindow gathers everything WindowInfo needs about a window
+func describeWindow(hwnd uintptr, monitors []Monitor) WindowInfo {
+	focus := windowFocusInfo(hwnd)
+
+	info := WindowInfo{
+		Handle:  uint64(hwnd),
+		Title:   focus.Title,
+		PID:     focus.PID,
+		Process: focus.Process,
+		Visible: windows.IsWindowVisible(windows.HWND(hwnd)) && !isCloaked(hwnd),
+		Monitor: -1,
+	}
+
+	class := make([]uint16, 256)
+	if n, err := windows.GetClassName(windows.HWND(hwnd), &class[0], int32(len(class))); err == nil {
+		info.Class = syscall.UTF16ToString(class[:n])
+	}
+
+	index := int32(GWL_EXSTYLE)
+	exStyle, _, _ := getWindowLongPtr.Call(hwnd, uintptr(index))
+	owner, _, _ := getWindowOwner.Call(hwnd, GW_OWNER)
+	info.TopLevel = exStyle&WS_EX_TOOLWINDOW == 0 && (owner == 0 || exStyle&WS_EX_APPWINDOW != 0)
+
+	minimized, _, _ := isIconic.Call(hwnd)
+	info.Minimized = minimized != 0
+
+	var rect windows.Rect
+	if ret, _, _ := getWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); ret != 0 {
+		info.Bounds = WindowBounds{
+			X:      int(rect.Left),
+			Y:      int(rect.Top),
+			Width:  int(rect.Right - rect.Left),
+			Height: int(rect.Bottom - rect.Top),
+		}
+	}
+
+	monitor, _, _ := monitorFromWindow.Call(hwnd, MONITOR_DEFAULTTONEAREST)
+	var mi MonitorInfoEx
+	mi.CbSize = uint32(unsafe.Sizeof(mi))
+	if r1, _, _ := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&mi))); r1 != 0 {
+		device := syscall.UTF16ToString(mi.Device[:])
+		for i, m := range monitors {
+			if m.Device == device {
+				info.Monitor = i
+				break
+			}
+		}
+	}
+
+	return info
+}
+
+// isCloaked reports whether DWM is hiding a window that is otherwise
+// visible, such as suspended Store apps or windows on other desktops
+func isCloaked(hwnd uintptr) bool {
+	var cloaked uint32
+	ret, _, _ := dwmGetWindowAttrib.Call(hwnd, DWMWA_CLOAKED, uintptr(unsafe.Pointer(&cloaked)), unsafe.Sizeof(cloaked))
+	return ret == 0 && cloaked != 0
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// How the overlay windows are shown
+const (
+	WindowModeOverlay    = "overlay"    // Fullscreen, always on top and click-through
+	WindowModeWindowed   = "windowed"   // A normal window, for customizing the layout
+	WindowModeBorderless = "borderless" // Covers the monitor without a frame or staying on top, for capturing
+)
+
+// EventWindowMode is emitted with {mode} when SetMode changes the mode
+const EventWindowMode = "window:mode"
+
+type windowModeFile struct {
+	Mode string `json:"mode"`
+}
+
+// GetMode returns how the overlay windows are shown
+func (ws *WindowService) GetMode() string {
+	ws.modeMu.Lock()
+	defer ws.modeMu.Unlock()
+
+	return ws.mode
+}
+
+// overlayMode reports whether the windows are fullscreen and click-through
+func (ws *WindowService) overlayMode() bool {
+	return ws.GetMode() == WindowModeOverlay
+}
+
+// SetMode reconfigures the overlay windows for a mode and saves it, so the
+// next launch starts in it
+func (ws *WindowService) SetMode(mode string) error {
+	if !validWindowMode(mode) {
+		return fmt.Errorf("unknown window mode %q", mode)
+	}
+
+	ws.modeMu.Lock()
+	if ws.mode == mode {
+		ws.modeMu.Unlock()
+		return nil
+	}
+	if err := saveWindowMode(ws.modePath, mode); err != nil {
+		ws.modeMu.Unlock()
+		return err
+	}
+	ws.mode = mode
+	ws.modeMu.Unlock()
+
+	monitors := ws.listMonitors()
+
+	ws.moveMu.Lock()
+	if WailsWindow != nil {
+		setWindowMode(WailsWindow, mode)
+	}
+	for _, o := range ws.overlays {
+		setWindowMode(o.window, mode)
+		o.placed = Monitor{}
+	}
+
+	// Place every window again, as the mode decides how it covers its
+	// monitor
+	for i, m := range monitors {
+		if m.ID == ws.placed.ID {
+			ws.moveMainWindowLocked(i, m)
+			break
+		}
+	}
+	ws.placeOverlaysLocked(monitors)
+	ws.moveMu.Unlock()
+
+	// Overlay mode starts click-through, other modes take the whole mouse
+	ws.setFocused(false)
+
+	fmt.Printf("Switched to %s mode\n", mode)
+
+	emitAppEvent(EventWindowMode, map[string]interface{}{
+		"mode": mode,
+	})
+
+	return nil
+}
+
+// setWindowMode changes the flags of an overlay window for a mode. It
+// must be placed on its monitor again afterwards.
+func setWindowMode(w *application.WebviewWindow, mode string) {
+	overlay := mode == WindowModeOverlay
+
+	if !overlay {
+		w.UnFullscreen()
+	}
+	w.SetFrameless(mode == WindowModeBorderless)
+	w.SetAlwaysOnTop(overlay)
+	w.SetIgnoreMouseEvents(overlay)
+}
+
+func validWindowMode(mode string) bool {
+	switch mode {
+	case WindowModeOverlay, WindowModeWindowed, WindowModeBorderless:
+		return true
+	}
+	return false
+}
+
+// windowModePath is where the mode is saved, in the user's config folder
+func windowModePath() string {
+	path := "window.json"
+	if dir, err := GetConfigDir(); err == nil {
+		path = filepath.Join(dir, path)
+	} else {
+		fmt.Println("Error getting config directory:", err)
+	}
+	return path
+}
+
+// loadWindowMode returns the saved mode, or overlay if there is none
+func loadWindowMode(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Println("Error loading window mode:", err)
+		}
+		return WindowModeOverlay
+	}
+
+	var file windowModeFile
+	if err := json.Unmarshal(data, &file); err != nil || !validWindowMode(file.Mode) {
+		fmt.Printf("Error parsing %s, using overlay mode\n", path)
+		return WindowModeOverlay
+	}
+
+	return file.Mode
+}
+
+func saveWindowMode(path, mode string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(windowModeFile{Mode: mode}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding window mode: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("error saving window mode: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error saving window mode: %w", err)
+	}
+
+	return nil
+}
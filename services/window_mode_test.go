@@ -0,0 +1,30 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetModeSaves(t *testing.T) {
+	ws := newWindowService(WindowModeOverlay, newFakeMonitorSource(testMonitors...))
+	// The config folder doesn't exist until the first save
+	ws.modePath = filepath.Join(t.TempDir(), "Smash Glass", "window.json")
+
+	if err := ws.SetMode("fullscreen"); err == nil {
+		t.Fatal("SetMode accepted an unknown mode")
+	}
+	if _, err := os.Stat(ws.modePath); !os.IsNotExist(err) {
+		t.Fatal("an unknown mode was saved")
+	}
+
+	if err := ws.SetMode(WindowModeBorderless); err != nil {
+		t.Fatalf("SetMode: %v", err)
+	}
+	if mode := ws.GetMode(); mode != WindowModeBorderless {
+		t.Fatalf("GetMode() = %s, want %s", mode, WindowModeBorderless)
+	}
+	if mode := loadWindowMode(ws.modePath); mode != WindowModeBorderless {
+		t.Fatalf("saved mode = %s, want %s", mode, WindowModeBorderless)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !windows && !linux
+
+package services
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// winAPI holds the Windows GDI procedures used for previews
+type winAPI struct{}
+
+func newWinAPI() *winAPI {
+	return nil
+}
+
+// captureScreen isn't supported on this platform
+func (ws *WindowService) captureScreen(x, y, width, height, outWidth, outHeight int) (*image.RGBA, error) {
+	return nil, fmt.Errorf("capturing the screen is not supported on this platform")
+}
+
+// unsupportedMonitorSource is used on platforms without monitor enumeration
+type unsupportedMonitorSource struct{}
+
+func newMonitorSource() MonitorSource {
+	return unsupportedMonitorSource{}
+}
+
+func (unsupportedMonitorSource) Monitors() ([]Monitor, error) {
+	return nil, fmt.Errorf("listing monitors is not supported on this platform")
+}
+
+// listWindows reports that windows can't be listed on this platform
+func listWindows() ([]WindowInfo, error) {
+	return nil, fmt.Errorf("listing windows is not supported on this platform")
+}
+
+// placeWindow moves a window onto a monitor and sizes it to fill it. Windows
+// are measured in logical pixels.
+func placeWindow(w *application.WebviewWindow, m Monitor) error {
+	w.SetPosition(m.logical(m.X), m.logical(m.Y))
+	w.SetSize(m.logical(m.Width), m.logical(m.Height))
+	return nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"golang.org/x/sys/windows"
+)
+
+type winAPI struct {
+	user32                 *windows.LazyDLL
+	gdi32                  *windows.LazyDLL
+	dwmapi                 *windows.LazyDLL
+	getDC                  *windows.LazyProc
+	releaseDC              *windows.LazyProc
+	createCompatibleDC     *windows.LazyProc
+	createCompatibleBitmap *windows.LazyProc
+	selectObject           *windows.LazyProc
+	bitBlt                 *windows.LazyProc
+	stretchBlt             *windows.LazyProc
+	setStretchBltMode      *windows.LazyProc
+	setBrushOrgEx          *windows.LazyProc
+	deleteDC               *windows.LazyProc
+	deleteObject           *windows.LazyProc
+	getDIBits              *windows.LazyProc
+	dwmGetWindowAttribute  *windows.LazyProc
+}
+
+func newWinAPI() *winAPI {
+	win := &winAPI{
+		user32: windows.NewLazySystemDLL("user32.dll"),
+		gdi32:  windows.NewLazySystemDLL("gdi32.dll"),
+		dwmapi: windows.NewLazySystemDLL("dwmapi.dll"),
+	}
+	win.getDC = win.user32.NewProc("GetDC")
+	win.releaseDC = win.user32.NewProc("ReleaseDC")
+	win.createCompatibleDC = win.gdi32.NewProc("CreateCompatibleDC")
+	win.createCompatibleBitmap = win.gdi32.NewProc("CreateCompatibleBitmap")
+	win.selectObject = win.gdi32.NewProc("SelectObject")
+	win.bitBlt = win.gdi32.NewProc("BitBlt")
+	win.stretchBlt = win.gdi32.NewProc("StretchBlt")
+	win.setStretchBltMode = win.gdi32.NewProc("SetStretchBltMode")
+	win.setBrushOrgEx = win.gdi32.NewProc("SetBrushOrgEx")
+	win.deleteDC = win.gdi32.NewProc("DeleteDC")
+	win.deleteObject = win.gdi32.NewProc("DeleteObject")
+	win.getDIBits = win.gdi32.NewProc("GetDIBits")
+	win.dwmGetWindowAttribute = win.dwmapi.NewProc("DwmGetWindowAttribute")
+
+	return win
+}
+
+const (
+	SRCCOPY  = 0x00CC0020
+	HALFTONE = 4
+)
+
+// captureScreen copies an area of the screen into an outWidth x outHeight
+// image using GDI. The area is scaled by GDI when the sizes differ, so
+// only the scaled pixels are ever read back.
+func (ws *WindowService) captureScreen(x, y, width, height, outWidth, outHeight int) (*image.RGBA, error) {
+	win := ws.winapi
+
+	// Get screen device context
+	hdcScreen, _, _ := win.getDC.Call(0)
+	if hdcScreen == 0 {
+		return nil, fmt.Errorf("GetDC failed")
+	}
+	defer win.releaseDC.Call(0, hdcScreen)
+
+	hdcMem, _, _ := win.createCompatibleDC.Call(hdcScreen)
+	if hdcMem == 0 {
+		return nil, fmt.Errorf("CreateCompatibleDC failed")
+	}
+	defer win.deleteDC.Call(hdcMem)
+
+	hbm, _, _ := win.createCompatibleBitmap.Call(hdcScreen, uintptr(outWidth), uintptr(outHeight))
+	if hbm == 0 {
+		return nil, fmt.Errorf("CreateCompatibleBitmap failed")
+	}
+	defer win.deleteObject.Call(hbm)
+
+	old, _, _ := win.selectObject.Call(hdcMem, hbm)
+	defer win.selectObject.Call(hdcMem, old)
+
+	// Copy screen into bitmap, averaging pixels when shrinking
+	var ret uintptr
+	if outWidth == width && outHeight == height {
+		ret, _, _ = win.bitBlt.Call(hdcMem, 0, 0, uintptr(width), uintptr(height), hdcScreen, uintptr(x), uintptr(y), SRCCOPY)
+	} else {
+		win.setStretchBltMode.Call(hdcMem, HALFTONE)
+		win.setBrushOrgEx.Call(hdcMem, 0, 0, 0)
+		ret, _, _ = win.stretchBlt.Call(hdcMem, 0, 0, uintptr(outWidth), uintptr(outHeight),
+			hdcScreen, uintptr(x), uintptr(y), uintptr(width), uintptr(height), SRCCOPY)
+	}
+	if ret == 0 {
+		return nil, fmt.Errorf("copying the screen failed")
+	}
+
+	// Create BITMAPINFO
+	type BITMAPINFOHEADER struct {
+		Size          uint32
+		Width         int32
+		Height        int32
+		Planes        uint16
+		BitCount      uint16
+		Compression   uint32
+		SizeImage     uint32
+		XPelsPerMeter int32
+		YPelsPerMeter int32
+		ClrUsed       uint32
+		ClrImportant  uint32
+	}
+
+	type BITMAPINFO struct {
+		Header BITMAPINFOHEADER
+		Colors [1]uint32
+	}
+
+	bi := BITMAPINFO{
+		Header: BITMAPINFOHEADER{
+			Size:        uint32(unsafe.Sizeof(BITMAPINFOHEADER{})),
+			Width:       int32(outWidth),
+			Height:      -int32(outHeight), // top-down DIB
+			Planes:      1,
+			BitCount:    32,
+			Compression: 0, // BI_RGB
+		},
+	}
+
+	// Read the BGRA pixels straight into the image and swap them to RGBA
+	rgba := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+
+	ret, _, _ = win.getDIBits.Call(hdcMem, hbm, 0, uintptr(outHeight),
+		uintptr(unsafe.Pointer(&rgba.Pix[0])), uintptr(unsafe.Pointer(&bi)), 0)
+	if ret == 0 {
+		return nil, fmt.Errorf("GetDIBits failed")
+	}
+
+	bgraToRGBA(rgba.Pix)
+
+	return rgba, nil
+}
+
+var setWindowPos = user32.NewProc("SetWindowPos")
+
+const (
+	SWP_NOSIZE     = 0x0001
+	SWP_NOMOVE     = 0x0002
+	SWP_NOZORDER   = 0x0004
+	SWP_NOACTIVATE = 0x0010
+)
+
+// placeWindow moves a window onto a monitor and sizes it to fill it, in
+// device pixels. It is moved first so that the window has the monitor's
+// DPI before it is sized.
+func placeWindow(w *application.WebviewWindow, m Monitor) error {
+	hwnd, err := w.NativeWindowHandle()
+	if err != nil {
+		return fmt.Errorf("error getting window handle: %w", err)
+	}
+
+	ret, _, err := setWindowPos.Call(hwnd, 0, uintptr(int32(m.X)), uintptr(int32(m.Y)), 0, 0,
+		SWP_NOSIZE|SWP_NOZORDER|SWP_NOACTIVATE)
+	if ret == 0 {
+		return fmt.Errorf("SetWindowPos failed: %v", err)
+	}
+
+	ret, _, err = setWindowPos.Call(hwnd, 0, 0, 0, uintptr(m.Width), uintptr(m.Height),
+		SWP_NOMOVE|SWP_NOZORDER|SWP_NOACTIVATE)
+	if ret == 0 {
+		return fmt.Errorf("SetWindowPos failed: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// x11Display is a connection to the X server with the atoms it needs
+// already looked up
+type x11Display struct {
+	conn  *xgb.Conn
+	root  xproto.Window
+	atoms map[string]xproto.Atom
+}
+
+// openX11Display connects to the X server and interns the named atoms
+func openX11Display(atoms ...string) (*x11Display, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to X server: %w", err)
+	}
+
+	d := &x11Display{
+		conn:  conn,
+		root:  xproto.Setup(conn).DefaultScreen(conn).Root,
+		atoms: make(map[string]xproto.Atom),
+	}
+
+	for _, name := range append([]string{"_NET_WM_NAME", "_NET_WM_PID", "UTF8_STRING"}, atoms...) {
+		reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error getting atom %s: %w", name, err)
+		}
+		d.atoms[name] = reply.Atom
+	}
+
+	return d, nil
+}
+
+func (d *x11Display) Close() {
+	d.conn.Close()
+}
+
+// property reads a window property, returning nil if it isn't set
+func (d *x11Display) property(win xproto.Window, atom, typ xproto.Atom) []byte {
+	reply, err := xproto.GetProperty(d.conn, false, win, atom, typ, 0, 1<<16).Reply()
+	if err != nil || reply == nil {
+		return nil
+	}
+	return reply.Value
+}
+
+// atomList reads a property holding a list of atoms or windows
+func (d *x11Display) atomList(win xproto.Window, atom, typ xproto.Atom) []uint32 {
+	value := d.property(win, atom, typ)
+	list := make([]uint32, 0, len(value)/4)
+	for i := 0; i+4 <= len(value); i += 4 {
+		list = append(list, xgb.Get32(value[i:]))
+	}
+	return list
+}
+
+// focusInfo looks up the title and owning process of a window
+func (d *x11Display) focusInfo(win xproto.Window) FocusInfo {
+	info := FocusInfo{Handle: uint64(win)}
+
+	info.Title = string(d.property(win, d.atoms["_NET_WM_NAME"], d.atoms["UTF8_STRING"]))
+	if info.Title == "" {
+		info.Title = string(d.property(win, xproto.AtomWmName, xproto.AtomString))
+	}
+
+	if pid := d.property(win, d.atoms["_NET_WM_PID"], xproto.AtomCardinal); len(pid) >= 4 {
+		info.PID = xgb.Get32(pid)
+		info.Process = processName(info.PID)
+	}
+
+	return info
+}
+
+// processName returns the executable name of a process
+func processName(pid uint32) string {
+	comm, err := os.ReadFile("/proc/" + strconv.FormatUint(uint64(pid), 10) + "/comm")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}